@@ -1,3 +1,9 @@
 module github.com/x86cloud/utils
 
 go 1.16
+
+require (
+	github.com/gin-gonic/gin v1.7.2
+	github.com/golang/protobuf v1.3.3
+	gopkg.in/yaml.v3 v3.0.1
+)
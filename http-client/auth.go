@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to a request. It is called for every
+// attempt, after the middlewares.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// Invalidator is implemented by authenticators with cached credentials. When
+// a request is answered with 401 the credentials are invalidated and the
+// request is sent once more.
+type Invalidator interface {
+	Invalidate()
+}
+
+// WithAuth sets the authenticator of every request of the client.
+func WithAuth(a Authenticator) ClientSettingFunc {
+	return func(c *Client) {
+		c.auth = a
+	}
+}
+
+// Auth sets the authenticator of the request, overriding the client's.
+func (r *request) Auth(a Authenticator) *request {
+	r.auth = a
+	return r
+}
+
+// authenticate returns the middleware applying a.
+func authenticate(a Authenticator) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if err := a.Authenticate(req); err != nil {
+				if req.Body != nil {
+					_ = req.Body.Close()
+				}
+				return nil, err
+			}
+			resp, err := next(req)
+
+			inv, ok := a.(Invalidator)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || !ok {
+				return resp, err
+			}
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return resp, err
+			}
+
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+			inv.Invalidate()
+
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			if err := a.Authenticate(retry); err != nil {
+				if retry.Body != nil {
+					_ = retry.Body.Close()
+				}
+				return nil, err
+			}
+			return next(retry)
+		}
+	}
+}
+
+// BasicAuth sends a username and password with HTTP Basic authentication.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerToken sends a static token in the Authorization header.
+type BearerToken string
+
+func (t BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(t))
+	return nil
+}
+
+// ClientCredentials fetches OAuth2 access tokens with the client credentials
+// grant (RFC 6749 section 4.4). Tokens are cached and refreshed ExpiryDelta
+// before they expire; a 401 answer drops the cached token. It is safe for
+// concurrent use, concurrent requests share one token fetch.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Params are sent with the token request, e.g. an audience.
+	Params url.Values
+	// ExpiryDelta defaults to 30 seconds.
+	ExpiryDelta time.Duration
+	// Client sends the token requests, DefaultClient by default.
+	Client *Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// tokenResponse is the successful token response of RFC 6749 section 5.1.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (c *ClientCredentials) Authenticate(req *http.Request) error {
+	token, err := c.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *ClientCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = ""
+}
+
+// Token returns the cached access token, fetching a new one when there is
+// none or it is about to expire.
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delta := c.ExpiryDelta
+	if delta == 0 {
+		delta = 30 * time.Second
+	}
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Add(delta).Before(c.expiry)) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	for key, values := range c.Params {
+		form[key] = append(form[key], values...)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	resp := tokenResponse{}
+	err := client.New(c.TokenURL).
+		WithContext(ctx).
+		Auth(BasicAuth{Username: url.QueryEscape(c.ClientID), Password: url.QueryEscape(c.ClientSecret)}).
+		Encode(FormCodec{}.ContentType(), form).
+		Post(nil).
+		Do(&resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("token response has no access_token")
+	}
+
+	c.token = resp.AccessToken
+	c.expiry = time.Time{}
+	if resp.ExpiresIn > 0 {
+		c.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return c.token, nil
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer issues numbered tokens on /token and serves /api to requests
+// carrying the latest one.
+func tokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int32) {
+	var issued int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			id, secret, ok := r.BasicAuth()
+			if !ok || id != "cli" || secret != "s3cret" || r.PostFormValue("grant_type") != "client_credentials" ||
+				r.PostFormValue("scope") != "read write" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			n := atomic.AddInt32(&issued, 1)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+		case "/api":
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", atomic.LoadInt32(&issued)) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &issued
+}
+
+func newCredentials(server *httptest.Server) *ClientCredentials {
+	return &ClientCredentials{
+		TokenURL:     server.URL + "/token",
+		ClientID:     "cli",
+		ClientSecret: "s3cret",
+		Scopes:       []string{"read", "write"},
+	}
+}
+
+func TestClientCredentialsConcurrent(t *testing.T) {
+	server, issued := tokenServer(t, 3600)
+	c := NewClient(server.URL, WithAuth(newCredentials(server)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.New("/api").Get().Do(nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if *issued != 1 {
+		t.Fatalf("fetched %d tokens, want 1", *issued)
+	}
+}
+
+func TestClientCredentialsRefresh(t *testing.T) {
+	server, issued := tokenServer(t, 60)
+	creds := newCredentials(server)
+	creds.ExpiryDelta = 2 * time.Minute
+
+	for i := 0; i < 3; i++ {
+		if err := New(server.URL + "/api").Auth(creds).Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *issued != 3 {
+		t.Fatalf("fetched %d tokens, want a new one for every request", *issued)
+	}
+}
+
+func TestClientCredentialsRetryOn401(t *testing.T) {
+	server, issued := tokenServer(t, 3600)
+	creds := newCredentials(server)
+
+	if err := New(server.URL + "/api").Auth(creds).Get().Do(nil); err != nil {
+		t.Fatal(err)
+	}
+	// another client rotated the token, the cached one is rejected now
+	atomic.AddInt32(issued, 1)
+
+	var body string
+	if err := New(server.URL + "/api").Auth(creds).Post([]byte("x")).Do(&body); err != nil || body != "ok" {
+		t.Fatalf("got %q, %v", body, err)
+	}
+	if *issued != 3 {
+		t.Fatalf("fetched %d tokens, want 3", *issued)
+	}
+}
+
+func TestClientCredentialsBadSecret(t *testing.T) {
+	server, _ := tokenServer(t, 3600)
+	creds := newCredentials(server)
+	creds.ClientSecret = "wrong"
+
+	err := New(server.URL + "/api").Auth(creds).Get().Do(nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 of the token endpoint", err)
+	}
+}
+
+type failingAuth struct{}
+
+func (failingAuth) Authenticate(req *http.Request) error {
+	return errors.New("no credentials")
+}
+
+func TestFailedAuthClosesBody(t *testing.T) {
+	var counter closeCounter
+	body, _, _ := counter.open()
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:1", body)
+
+	send := authenticate(failingAuth{})(func(req *http.Request) (*http.Response, error) {
+		t.Fatal("a request that failed to authenticate was sent")
+		return nil, nil
+	})
+	if _, err := send(req); err == nil {
+		t.Fatal("expected the authenticator error")
+	}
+	if counter.closed != 1 {
+		t.Fatalf("body closed %d times, want once", counter.closed)
+	}
+}
+
+func TestStaticAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		auth Authenticator
+		want string
+	}{
+		{BasicAuth{Username: "root", Password: "pw"}, "Basic cm9vdDpwdw=="},
+		{BearerToken("abc"), "Bearer abc"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := New(server.URL).Auth(tt.auth).Get().Do(&got); err != nil || got != tt.want {
+			t.Fatalf("got %q, %v, want %q", got, err, tt.want)
+		}
+	}
+}
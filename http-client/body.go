@@ -0,0 +1,365 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// bodySource opens the request body. size is -1 when unknown, in which case
+// the body is sent chunked.
+type bodySource struct {
+	open func() (rc io.ReadCloser, size int64, err error)
+	// size returns the size of the body without holding it open, so that
+	// open can be deferred until the body is read. When it is nil the body
+	// is opened as soon as each attempt is prepared.
+	size func() (int64, error)
+	// rewindable is false for single-use readers, which are neither retried
+	// nor signed.
+	rewindable bool
+}
+
+func constSize(size int64) func() (int64, error) {
+	return func() (int64, error) { return size, nil }
+}
+
+// ProgressFunc is called while the request body is sent with the bytes sent
+// so far and the total size, -1 when unknown. A retried request starts again
+// from zero.
+type ProgressFunc func(sent, total int64)
+
+// Body streams r as the request body. *bytes.Reader, *bytes.Buffer and
+// *strings.Reader have a known size and can be re-sent on retries: their
+// remaining content is taken when Body is called and every attempt reads its
+// own copy. Other readers are read once and sent chunked, and closed once
+// sent if they are io.ReadClosers.
+func (r *request) Body(reader io.Reader) *request {
+	var data []byte
+	switch b := reader.(type) {
+	case *bytes.Reader:
+		data, _ = ioutil.ReadAll(b)
+	case *strings.Reader:
+		data, _ = ioutil.ReadAll(b)
+	case *bytes.Buffer:
+		data, _ = ioutil.ReadAll(b)
+	default:
+		var once sync.Once
+		r.setBody(bodySource{
+			size: constSize(-1),
+			open: func() (io.ReadCloser, int64, error) {
+				opened := false
+				once.Do(func() { opened = true })
+				if !opened {
+					return nil, 0, errors.New("request body reader was already consumed")
+				}
+				if rc, ok := reader.(io.ReadCloser); ok {
+					return rc, -1, nil
+				}
+				return ioutil.NopCloser(reader), -1, nil
+			},
+		})
+		return r
+	}
+
+	size := int64(len(data))
+	r.setBody(bodySource{
+		rewindable: true,
+		size:       constSize(size),
+		open: func() (io.ReadCloser, int64, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), size, nil
+		},
+	})
+	return r
+}
+
+// BodyFunc sets a body that is opened by open for every attempt, so it can be
+// retried and signed. size is -1 when unknown. The body is opened before the
+// attempt goes through the middlewares, which must close it when they do not
+// send the request.
+func (r *request) BodyFunc(open func() (rc io.ReadCloser, size int64, err error)) *request {
+	r.setBody(bodySource{open: open, rewindable: true})
+	return r
+}
+
+// File streams the file at path as the request body.
+func (r *request) File(path string) *request {
+	r.setBody(bodySource{
+		rewindable: true,
+		size:       func() (int64, error) { return fileSize(path) },
+		open:       func() (io.ReadCloser, int64, error) { return openFile(path) },
+	})
+	return r
+}
+
+// Form sends values as an application/x-www-form-urlencoded body.
+func (r *request) Form(values url.Values) *request {
+	return r.Encode(FormCodec{}.ContentType(), values)
+}
+
+func (r *request) setBody(source bodySource) {
+	r.body = nil
+	r.bodySource = &source
+}
+
+// OnUploadProgress sets a callback reporting how much of the body was sent.
+func (r *request) OnUploadProgress(fn ProgressFunc) *request {
+	r.uploadProgress = fn
+	return r
+}
+
+func fileSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func openFile(path string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// progressReader reports the bytes read from it.
+type progressReader struct {
+	io.ReadCloser
+	sent, total int64
+	fn          ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if n > 0 {
+		p.sent += int64(n)
+		p.fn(p.sent, p.total)
+	}
+	return n, err
+}
+
+// lazyBody opens the body on its first read, so that a request dropped
+// before it is sent, e.g. by a middleware, holds no open file or pipe.
+type lazyBody struct {
+	open func() (io.ReadCloser, error)
+
+	mu     sync.Mutex
+	rc     io.ReadCloser
+	closed bool
+}
+
+func (b *lazyBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return 0, http.ErrBodyReadAfterClose
+	}
+	if b.rc == nil {
+		rc, err := b.open()
+		if err != nil {
+			b.mu.Unlock()
+			return 0, err
+		}
+		b.rc = rc
+	}
+	rc := b.rc
+	b.mu.Unlock()
+	return rc.Read(p)
+}
+
+func (b *lazyBody) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.rc != nil {
+		return b.rc.Close()
+	}
+	return nil
+}
+
+// multipartPart is a field or a file of a multipart form.
+type multipartPart struct {
+	field    string
+	filename string
+	value    string
+	open     func() (io.ReadCloser, int64, error)
+	size     func() (int64, error)
+}
+
+// AddFormField adds a field to a multipart/form-data body.
+func (r *request) AddFormField(name, value string) *request {
+	r.multipart = append(r.multipart, multipartPart{field: name, value: value})
+	return r.setMultipart()
+}
+
+// AddFormFile adds a file read from reader to a multipart/form-data body.
+// Like Body, only sized readers can be re-sent on retries.
+func (r *request) AddFormFile(field, filename string, reader io.Reader) *request {
+	single := &request{}
+	single.Body(reader)
+	source := *single.bodySource
+	r.multipart = append(r.multipart, multipartPart{field: field, filename: filename, open: source.open, size: source.size})
+	if !source.rewindable {
+		r.multipartOnce = true
+	}
+	return r.setMultipart()
+}
+
+// AddFormFilePath adds the file at path to a multipart/form-data body. The
+// file is streamed, not loaded into memory.
+func (r *request) AddFormFilePath(field, path string) *request {
+	r.multipart = append(r.multipart, multipartPart{
+		field:    field,
+		filename: filepath.Base(path),
+		open:     func() (io.ReadCloser, int64, error) { return openFile(path) },
+		size:     func() (int64, error) { return fileSize(path) },
+	})
+	return r.setMultipart()
+}
+
+// setMultipart makes the form parts the body, written through an io.Pipe
+// while the request is sent.
+func (r *request) setMultipart() *request {
+	if r.boundary == "" {
+		b := make([]byte, 16)
+		_, _ = rand.Read(b)
+		r.boundary = hex.EncodeToString(b)
+	}
+	r.headers.Set("Content-Type", "multipart/form-data; boundary="+r.boundary)
+
+	parts, boundary := r.multipart, r.boundary
+	r.setBody(bodySource{
+		rewindable: !r.multipartOnce,
+		open: func() (io.ReadCloser, int64, error) {
+			return openMultipart(parts, boundary)
+		},
+		size: func() (int64, error) {
+			sizes := make([]int64, len(parts))
+			for i, part := range parts {
+				if part.size == nil {
+					continue
+				}
+				size, err := part.size()
+				if err != nil {
+					return 0, fmt.Errorf("form file %q: %w", part.field, err)
+				}
+				sizes[i] = size
+			}
+			return multipartSize(parts, sizes, boundary), nil
+		},
+	})
+	return r
+}
+
+func openMultipart(parts []multipartPart, boundary string) (io.ReadCloser, int64, error) {
+	files := make([]io.ReadCloser, len(parts))
+	sizes := make([]int64, len(parts))
+	closeAll := func() {
+		for _, f := range files {
+			if f != nil {
+				_ = f.Close()
+			}
+		}
+	}
+	for i, part := range parts {
+		if part.open == nil {
+			continue
+		}
+		f, size, err := part.open()
+		if err != nil {
+			closeAll()
+			return nil, 0, fmt.Errorf("form file %q: %w", part.field, err)
+		}
+		files[i], sizes[i] = f, size
+	}
+
+	size := multipartSize(parts, sizes, boundary)
+	pr, pw := io.Pipe()
+	go func() {
+		defer closeAll()
+		w := multipart.NewWriter(pw)
+		_ = w.SetBoundary(boundary)
+		for i, part := range parts {
+			if files[i] == nil {
+				if err := w.WriteField(part.field, part.value); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+				continue
+			}
+			fw, err := w.CreatePart(fileHeader(part))
+			if err == nil {
+				_, err = io.Copy(fw, files[i])
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(w.Close())
+	}()
+	return pr, size, nil
+}
+
+func fileHeader(part multipartPart) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		escapeQuotes(part.field), escapeQuotes(part.filename)))
+	h.Set("Content-Type", "application/octet-stream")
+	return h
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// multipartSize computes the encoded size of the form by writing it without
+// the file contents, or returns -1 when a file size is unknown.
+func multipartSize(parts []multipartPart, sizes []int64, boundary string) int64 {
+	var counter countingWriter
+	w := multipart.NewWriter(&counter)
+	_ = w.SetBoundary(boundary)
+	for i, part := range parts {
+		if part.open == nil {
+			_ = w.WriteField(part.field, part.value)
+			continue
+		}
+		if sizes[i] < 0 {
+			return -1
+		}
+		_, _ = w.CreatePart(fileHeader(part))
+		counter += countingWriter(sizes[i])
+	}
+	_ = w.Close()
+	return int64(counter)
+}
+
+type countingWriter int64
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	*c += countingWriter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,293 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadServer answers with the length, transfer encoding and content of the
+// body, or with the fields and files of a multipart form.
+func uploadServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			reader, err := r.MultipartReader()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				data, _ := ioutil.ReadAll(part)
+				fmt.Fprintf(w, "%s(%s)=%s;", part.FormName(), part.FileName(), data)
+			}
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%d %v %s", r.ContentLength, r.TransferEncoding, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFileUploadProgress(t *testing.T) {
+	server := uploadServer(t)
+	path := filepath.Join(t.TempDir(), "image.iso")
+	content := bytes.Repeat([]byte("x"), 256<<10)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent, total int64
+	var body string
+	err := New(server.URL).
+		File(path).
+		OnUploadProgress(func(s, tt int64) { sent, total = s, tt }).
+		Put(nil).
+		Do(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := fmt.Sprintf("%d [] %s", len(content), content); body != want {
+		t.Fatalf("server got %.40q...", body)
+	}
+	if sent != int64(len(content)) || total != int64(len(content)) {
+		t.Fatalf("progress %d/%d, want %d", sent, total, len(content))
+	}
+}
+
+func TestStreamingBody(t *testing.T) {
+	server := uploadServer(t)
+
+	var body string
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("streamed"))
+		_ = pw.Close()
+	}()
+	if err := New(server.URL).Body(pr).Post(nil).Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "-1 [chunked] streamed" {
+		t.Fatalf("got %q", body)
+	}
+
+	if err := New(server.URL).Body(strings.NewReader("sized")).Post(nil).Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "5 [] sized" {
+		t.Fatalf("got %q", body)
+	}
+}
+
+func TestSignedReaderBody(t *testing.T) {
+	server := uploadServer(t)
+	signer := HMACSigner{AccessKey: "ak", SecretKey: "sk", Now: signingTime}
+
+	for _, reader := range []io.Reader{bytes.NewReader([]byte("hello")), strings.NewReader("hello")} {
+		var body string
+		err := NewClient(server.URL, WithAuth(signer)).New("/").Body(reader).Post(nil).Do(&body)
+		if err != nil {
+			t.Fatalf("%T: %v", reader, err)
+		}
+		if body != "5 [] hello" {
+			t.Fatalf("%T: server got %q, hashing the body must not consume it", reader, body)
+		}
+	}
+}
+
+// closeCounter records how many bodies were opened and closed.
+type closeCounter struct {
+	opened, closed int
+}
+
+func (c *closeCounter) open() (io.ReadCloser, int64, error) {
+	c.opened++
+	return &countedBody{Reader: strings.NewReader("hello"), counter: c}, 5, nil
+}
+
+type countedBody struct {
+	io.Reader
+	counter *closeCounter
+}
+
+func (b *countedBody) Close() error {
+	b.counter.closed++
+	return nil
+}
+
+func TestBodyReaders(t *testing.T) {
+	server := uploadServer(t)
+
+	var counter closeCounter
+	rc, _, _ := counter.open()
+	var body string
+	if err := New(server.URL).Body(rc).Post(nil).Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "-1 [chunked] hello" || counter.closed != 1 {
+		t.Fatalf("got %q, closed %d times, want the io.ReadCloser closed once", body, counter.closed)
+	}
+
+	buf := bytes.NewBufferString("taken")
+	r := New(server.URL).Body(buf).Post(nil)
+	buf.Reset()
+	buf.WriteString("later")
+	if err := r.Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "5 [] taken" {
+		t.Fatalf("got %q, the buffer content must be taken when Body is called", body)
+	}
+}
+
+func TestBodyFunc(t *testing.T) {
+	server := uploadServer(t)
+
+	var counter closeCounter
+	var body string
+	if err := New(server.URL).BodyFunc(counter.open).Post(nil).Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "5 [] hello" {
+		t.Fatalf("got %q", body)
+	}
+	if counter.opened != 1 || counter.closed != 1 {
+		t.Fatalf("opened %d bodies and closed %d for one attempt, want 1", counter.opened, counter.closed)
+	}
+}
+
+func TestFailedRequestClosesBody(t *testing.T) {
+	var dropped io.ReadCloser
+	drop := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			dropped = req.Body
+			return nil, errors.New("refused")
+		}
+	}
+	path := filepath.Join(t.TempDir(), "body")
+	if err := ioutil.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := New("http://127.0.0.1:1").Use(drop).File(path).Post(nil).Do(nil); err == nil {
+		t.Fatal("expected the middleware error")
+	}
+	if lazy, ok := dropped.(*lazyBody); !ok || lazy.rc != nil {
+		t.Fatalf("a file body was opened before it was read: %#v", dropped)
+	}
+
+	closeAndDrop := func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			_ = req.Body.Close()
+			return nil, errors.New("refused")
+		}
+	}
+	var refused closeCounter
+	if err := New("http://127.0.0.1:1").Use(closeAndDrop).BodyFunc(refused.open).Post(nil).Do(nil); err == nil {
+		t.Fatal("expected the middleware error")
+	}
+	if refused.opened != 1 || refused.closed != 1 {
+		t.Fatalf("a request dropped by a middleware opened %d bodies and closed %d, want 1", refused.opened, refused.closed)
+	}
+
+	var failed closeCounter
+	if err := New("http://127.0.0.1:1").BodyFunc(failed.open).Post(nil).Do(nil); err == nil {
+		t.Fatal("expected the connection to be refused")
+	}
+	if failed.opened != 1 || failed.closed != 1 {
+		t.Fatalf("a failed request opened %d bodies and closed %d, want 1", failed.opened, failed.closed)
+	}
+}
+
+func TestStreamingBodyRetry(t *testing.T) {
+	server, calls, bodies := flakyServer(t, 1, nil)
+
+	err := New(server.URL).Retry(fastRetry).Body(bytes.NewReader([]byte("again"))).Put(nil).Do(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 2 || (*bodies)[1] != "again" {
+		t.Fatalf("got %d calls with bodies %q", *calls, *bodies)
+	}
+
+	*calls = 0
+	pr, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("once"))
+		_ = pw.Close()
+	}()
+	if err := New(server.URL).Retry(fastRetry).Body(pr).Put(nil).Do(nil); err == nil {
+		t.Fatal("a single-use body must not be retried")
+	}
+}
+
+func TestMultipart(t *testing.T) {
+	server := uploadServer(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := ioutil.WriteFile(path, []byte("log line"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var sent, total int64
+	var body string
+	err := New(server.URL).
+		AddFormField("cluster", "prod").
+		AddFormFilePath("log", path).
+		AddFormFile("meta", "meta.json", strings.NewReader(`{"a":1}`)).
+		OnUploadProgress(func(s, tt int64) { sent, total = s, tt }).
+		Post(nil).
+		Do(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `cluster()=prod;log(app.log)=log line;meta(meta.json)={"a":1};`; body != want {
+		t.Fatalf("got %q, want %q", body, want)
+	}
+	if total <= 0 || sent != total {
+		t.Fatalf("progress %d/%d, the size of the form must be known", sent, total)
+	}
+}
+
+func TestMultipartMissingFile(t *testing.T) {
+	err := New("http://127.0.0.1:1").AddFormFilePath("f", filepath.Join(t.TempDir(), "missing")).Post(nil).Do(nil)
+	if !os.IsNotExist(unwrapAll(err)) {
+		t.Fatalf("got %v, want a not exist error", err)
+	}
+}
+
+func unwrapAll(err error) error {
+	for {
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok || u.Unwrap() == nil {
+			return err
+		}
+		err = u.Unwrap()
+	}
+}
+
+func TestForm(t *testing.T) {
+	server := uploadServer(t)
+
+	var body string
+	if err := New(server.URL).Form(map[string][]string{"a": {"1", "2"}}).Post(nil).Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "7 [] a=1&a=2" {
+		t.Fatalf("got %q", body)
+	}
+}
@@ -1,29 +1,298 @@
 package client
 
 import (
+	"context"
 	"crypto/tls"
+	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultClient is used by New.
+var DefaultClient = NewClient("")
+
+// Client is a long-lived HTTP client. It holds a base URL, default headers and
+// one tuned Transport whose keep-alive connections are reused by every
+// request created from it. A Client is safe for concurrent use.
+type Client struct {
+	baseURL string
+	headers http.Header
+	tls     *tls.Config
+	// timeout limits every request that does not set its own.
+	timeout time.Duration
+	retry   *RetryPolicy
+
+	middlewares []Middleware
+	auth        Authenticator
+	limiters    []*limiter
+
+	transport *http.Transport
+	client    *http.Client
+	// tuning is applied to a copy of transport once all options ran, so that
+	// a Transport passed to WithTransport is not changed, whatever the order
+	// of the options.
+	tuning []func(*http.Transport)
+
+	preHandlers  []PreHandler
+	postHandlers []PostHandler
+	errorType    reflect.Type
+
+	mu sync.Mutex
+	// tlsTransports holds transports for requests that override the TLS
+	// config, so that they keep their connections too. At most
+	// maxTLSTransports are kept, the oldest is dropped first.
+	tlsTransports map[*tls.Config]*http.Transport
+	tlsConfigs    []*tls.Config
+
+	// err is an invalid setting, returned by Do of every request.
+	err error
+}
+
+type ClientSettingFunc func(*Client)
+
+// NewClient creates a Client for baseURL, which is prepended to the uri of
+// every request and may be empty.
+func NewClient(baseURL string, opts ...ClientSettingFunc) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 16
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.ForceAttemptHTTP2 = true
+
+	c := &Client{
+		baseURL:       strings.TrimSuffix(baseURL, "/"),
+		headers:       http.Header{},
+		transport:     transport,
+		tlsTransports: map[*tls.Config]*http.Transport{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.tls != nil {
+		tls := c.tls
+		c.tune(func(t *http.Transport) { t.TLSClientConfig = tls })
+	}
+	if len(c.tuning) > 0 {
+		c.transport = c.transport.Clone()
+		for _, tune := range c.tuning {
+			tune(c.transport)
+		}
+	}
+	c.client = &http.Client{Transport: c.transport}
+	return c
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) ClientSettingFunc {
+	return func(c *Client) {
+		c.headers.Add(key, value)
+	}
+}
+
+func WithTLS(tls *tls.Config) ClientSettingFunc {
+	return func(c *Client) {
+		c.tls = tls
+	}
+}
+
+// WithTransport replaces the tuned default Transport. The other transport
+// options apply to a copy of it, transport itself is not changed.
+func WithTransport(transport *http.Transport) ClientSettingFunc {
+	return func(c *Client) {
+		c.transport = transport
+	}
+}
+
+func WithMaxIdleConnsPerHost(n int) ClientSettingFunc {
+	return func(c *Client) {
+		c.tune(func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+			if t.MaxIdleConns != 0 && t.MaxIdleConns < n {
+				t.MaxIdleConns = n
+			}
+		})
+	}
+}
+
+func WithIdleConnTimeout(d time.Duration) ClientSettingFunc {
+	return func(c *Client) {
+		c.tune(func(t *http.Transport) { t.IdleConnTimeout = d })
+	}
+}
+
+// WithTimeout limits every request, from dialing to reading the body, unless
+// the request sets its own with SetTimeout.
+func WithTimeout(d time.Duration) ClientSettingFunc {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithDialTimeout limits establishing the TCP connection.
+func WithDialTimeout(d time.Duration) ClientSettingFunc {
+	return func(c *Client) {
+		dial := (&net.Dialer{
+			Timeout:   d,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+		c.tune(func(t *http.Transport) { t.DialContext = dial })
+	}
+}
+
+// WithTLSHandshakeTimeout limits the TLS handshake.
+func WithTLSHandshakeTimeout(d time.Duration) ClientSettingFunc {
+	return func(c *Client) {
+		c.tune(func(t *http.Transport) { t.TLSHandshakeTimeout = d })
+	}
+}
+
+// WithResponseHeaderTimeout limits waiting for the response headers after the
+// request has been written. It does not include reading the body.
+func WithResponseHeaderTimeout(d time.Duration) ClientSettingFunc {
+	return func(c *Client) {
+		c.tune(func(t *http.Transport) { t.ResponseHeaderTimeout = d })
+	}
+}
+
+// tune records a change to the transport of the client.
+func (c *Client) tune(fn func(*http.Transport)) {
+	c.tuning = append(c.tuning, fn)
+}
+
+// New creates a request for uri, relative to the base URL of the client.
+func (c *Client) New(uri string) *request {
+	if c.baseURL != "" && !strings.Contains(uri, "://") {
+		uri = c.baseURL + "/" + strings.TrimPrefix(uri, "/")
+	}
+	u, err := url.Parse(uri)
+	if err == nil && (u.Scheme == "" || u.Host == "") {
+		err = fmt.Errorf("invalid request url %q: scheme and host are required", uri)
+	}
+	if c.err != nil {
+		err = c.err
+	}
+	return &request{
+		client:  c,
+		method:  http.MethodGet,
+		url:     u,
+		query:   url.Values{},
+		headers: http.Header{},
+		err:     err,
+	}
+}
+
+// CloseIdleConnections closes the idle keep-alive connections of the client.
+func (c *Client) CloseIdleConnections() {
+	c.transport.CloseIdleConnections()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, t := range c.tlsTransports {
+		t.CloseIdleConnections()
+	}
+}
+
+// httpClient returns the http.Client for a request with the given overrides.
+func (c *Client) httpClient(tls *tls.Config) *http.Client {
+	if tls == nil {
+		return c.client
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	transport, ok := c.tlsTransports[tls]
+	if !ok {
+		if len(c.tlsConfigs) == maxTLSTransports {
+			oldest := c.tlsConfigs[0]
+			c.tlsTransports[oldest].CloseIdleConnections()
+			delete(c.tlsTransports, oldest)
+			c.tlsConfigs = c.tlsConfigs[1:]
+		}
+		transport = c.transport.Clone()
+		transport.TLSClientConfig = tls
+		c.tlsTransports[tls] = transport
+		c.tlsConfigs = append(c.tlsConfigs, tls)
+	}
+	return &http.Client{Transport: transport}
+}
+
+// maxTLSTransports bounds the transports kept for per-request TLS configs, so
+// that a config built for every request does not leak its transport.
+const maxTLSTransports = 8
+
+// request is a chainable request builder. Options can be set in any order
+// and the http.Request is only built by Do, so errors from parsing the URL or
+// building the request are returned there. A builder is not safe for
+// concurrent use; Clone it for each goroutine.
 type request struct {
-	urlValues *url.Values
-	headers   *http.Header
-	url       string
+	client *Client
+	method string
+	url    *url.URL
+	// pathParams are the values of the {name} placeholders of the url path.
+	pathParams map[string]string
+	query      url.Values
+	headers    http.Header
+	body       []byte
+	// bodySource replaces body for streamed bodies.
+	bodySource       *bodySource
+	uploadProgress   ProgressFunc
+	downloadProgress ProgressFunc
+	multipart        []multipartPart
+	multipartOnce    bool
+	boundary         string
 
+	ctx     context.Context
 	tls     *tls.Config
-	request *http.Request
 	timeout time.Duration
+	retry   *RetryPolicy
+
+	middlewares []Middleware
+	auth        Authenticator
+
+	preHandlers  []PreHandler
+	postHandlers []PostHandler
+	errorType    reflect.Type
+
+	// err is the first construction error, reported by Do.
+	err error
 }
 
+// New creates a request on DefaultClient.
 func New(uri string) *request {
-	query, _ := url.ParseQuery(uri)
-	return &request{
-		url:       uri,
-		headers:   &http.Header{},
-		urlValues: &query,
+	return DefaultClient.New(uri)
+}
+
+// Clone returns a deep copy of the builder that can be changed and sent
+// independently of r.
+func (r *request) Clone() *request {
+	clone := *r
+	clone.query = url.Values{}
+	for key, values := range r.query {
+		clone.query[key] = append([]string(nil), values...)
+	}
+	clone.headers = r.headers.Clone()
+	if r.url != nil {
+		u := *r.url
+		clone.url = &u
+	}
+	if r.pathParams != nil {
+		clone.pathParams = map[string]string{}
+		for name, value := range r.pathParams {
+			clone.pathParams[name] = value
+		}
+	}
+	if r.body != nil {
+		clone.body = append([]byte(nil), r.body...)
 	}
+	clone.preHandlers = append([]PreHandler(nil), r.preHandlers...)
+	clone.postHandlers = append([]PostHandler(nil), r.postHandlers...)
+	clone.middlewares = append([]Middleware(nil), r.middlewares...)
+	clone.multipart = append([]multipartPart(nil), r.multipart...)
+	return &clone
 }
 
 func (c *request) AddHeader(key, value string) *request {
@@ -39,13 +308,13 @@ func (c *request) AddHeaders(headers map[string]string) *request {
 }
 
 func (c *request) AddQuery(key, value string) *request {
-	c.urlValues.Add(key, value)
+	c.query.Add(key, value)
 	return c
 }
 
 func (c *request) AddQueries(queries map[string]string) *request {
 	for key, value := range queries {
-		c.urlValues.Add(key, value)
+		c.query.Add(key, value)
 	}
 	return c
 }
@@ -0,0 +1,133 @@
+package client
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCountingServer returns a server that counts the connections it accepts.
+func newCountingServer(tb testing.TB) (*httptest.Server, *int64) {
+	var conns int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"test"}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	server.Start()
+	tb.Cleanup(server.Close)
+	return server, &conns
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	server, conns := newCountingServer(t)
+	c := NewClient(server.URL, WithHeader("X-Test", "1"))
+
+	for i := 0; i < 10; i++ {
+		if err := c.New("/user").Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt64(conns); n != 1 {
+		t.Fatalf("expected 1 connection, got %d", n)
+	}
+}
+
+func TestTLSTransportsBounded(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	c := NewClient(server.URL)
+	shared := server.Client().Transport.(*http.Transport).TLSClientConfig
+
+	for i := 0; i < 3*maxTLSTransports; i++ {
+		if err := c.New("/").TLS(shared.Clone()).Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.New("/").TLS(shared).Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(c.tlsTransports); n > maxTLSTransports {
+		t.Fatalf("kept %d transports, want at most %d", n, maxTLSTransports)
+	}
+}
+
+func TestWithTransportTuning(t *testing.T) {
+	shared := &http.Transport{MaxIdleConnsPerHost: 2}
+	c := NewClient("",
+		WithIdleConnTimeout(time.Minute),
+		WithTransport(shared),
+		WithMaxIdleConnsPerHost(8),
+		WithTLSHandshakeTimeout(time.Second),
+		WithResponseHeaderTimeout(2*time.Second),
+		WithDialTimeout(3*time.Second),
+		WithTLS(&tls.Config{ServerName: "example.com"}))
+
+	if shared.MaxIdleConnsPerHost != 2 || shared.IdleConnTimeout != 0 || shared.DialContext != nil ||
+		shared.TLSClientConfig != nil && shared.TLSClientConfig.ServerName != "" {
+		t.Fatalf("the transport passed to WithTransport was changed: %+v", shared)
+	}
+	tuned := c.transport
+	if tuned == shared {
+		t.Fatal("the options were not applied to a copy")
+	}
+	if tuned.IdleConnTimeout != time.Minute || tuned.MaxIdleConnsPerHost != 8 ||
+		tuned.TLSHandshakeTimeout != time.Second || tuned.ResponseHeaderTimeout != 2*time.Second ||
+		tuned.DialContext == nil || tuned.TLSClientConfig.ServerName != "example.com" {
+		t.Fatalf("options before and after WithTransport must both apply: %+v", tuned)
+	}
+
+	if c := NewClient("", WithTransport(shared)); c.transport != shared {
+		t.Fatal("an untuned transport must be used as is")
+	}
+}
+
+func BenchmarkClient(b *testing.B) {
+	server, conns := newCountingServer(b)
+	c := NewClient(server.URL)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.New("/user").Get().Do(nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(conns)), "conns")
+}
+
+func BenchmarkTransportPerRequest(b *testing.B) {
+	server, conns := newCountingServer(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// what request.Do used to do: a fresh Transport for every call
+		c := NewClient(server.URL)
+		if err := c.New("/user").Get().Do(nil); err != nil {
+			b.Fatal(err)
+		}
+		c.CloseIdleConnections()
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(conns)), "conns")
+}
+
+func BenchmarkClientParallel(b *testing.B) {
+	server, conns := newCountingServer(b)
+	c := NewClient(server.URL)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := c.New("/user").Get().Do(nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.ReportMetric(float64(atomic.LoadInt64(conns)), "conns")
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes request bodies and decodes response bodies of one media type.
+type Codec interface {
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]Codec{}
+)
+
+func init() {
+	RegisterCodec(JSONCodec{}, "application/json", "text/json")
+	RegisterCodec(XMLCodec{}, "application/xml", "text/xml")
+	RegisterCodec(YAMLCodec{}, "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml")
+	RegisterCodec(FormCodec{}, "application/x-www-form-urlencoded")
+	RegisterCodec(ProtobufCodec{}, "application/x-protobuf", "application/protobuf")
+}
+
+// RegisterCodec registers c for its ContentType and any additional media
+// types, replacing codecs registered before.
+func RegisterCodec(c Codec, mediaTypes ...string) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[c.ContentType()] = c
+	for _, t := range mediaTypes {
+		codecs[t] = c
+	}
+}
+
+// CodecFor returns the codec for a Content-Type header value. Structured
+// syntax suffixes such as application/problem+json use the codec of the
+// suffix.
+func CodecFor(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	if c, ok := codecs[mediaType]; ok {
+		return c, true
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		c, ok := codecs["application/"+mediaType[i+1:]]
+		return c, ok
+	}
+	return nil, false
+}
+
+type JSONCodec struct{}
+
+func (JSONCodec) ContentType() string                        { return "application/json" }
+func (JSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type XMLCodec struct{}
+
+func (XMLCodec) ContentType() string                        { return "application/xml" }
+func (XMLCodec) Marshal(v interface{}) ([]byte, error)      { return xml.Marshal(v) }
+func (XMLCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+type YAMLCodec struct{}
+
+func (YAMLCodec) ContentType() string                        { return "application/yaml" }
+func (YAMLCodec) Marshal(v interface{}) ([]byte, error)      { return yaml.Marshal(v) }
+func (YAMLCodec) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+
+// FormCodec handles url.Values, map[string]string, map[string][]string and
+// structs encoded by EncodeValues.
+type FormCodec struct{}
+
+func (FormCodec) ContentType() string { return "application/x-www-form-urlencoded" }
+
+func (FormCodec) Marshal(v interface{}) ([]byte, error) {
+	switch form := v.(type) {
+	case url.Values:
+		return []byte(form.Encode()), nil
+	case map[string][]string:
+		return []byte(url.Values(form).Encode()), nil
+	case map[string]string:
+		values := url.Values{}
+		for key, value := range form {
+			values.Set(key, value)
+		}
+		return []byte(values.Encode()), nil
+	}
+	values, err := EncodeValues(v)
+	if err != nil {
+		return nil, fmt.Errorf("form codec: %w", err)
+	}
+	return []byte(values.Encode()), nil
+}
+
+func (FormCodec) Unmarshal(data []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return err
+	}
+	switch form := v.(type) {
+	case *url.Values:
+		*form = values
+	case *map[string][]string:
+		*form = values
+	case *map[string]string:
+		*form = map[string]string{}
+		for key := range values {
+			(*form)[key] = values.Get(key)
+		}
+	default:
+		return fmt.Errorf("form codec cannot decode into %T", v)
+	}
+	return nil
+}
+
+// ProtobufCodec handles proto.Message values.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) ContentType() string { return "application/x-protobuf" }
+
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protobuf codec cannot encode %T", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protobuf codec cannot decode into %T", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// decode stores body into out. *[]byte and *string receive the raw body,
+// other values are decoded with the codec of contentType, JSON when the
+// response has none.
+func decode(contentType string, body []byte, out interface{}) error {
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*out = body
+		return nil
+	case *string:
+		*out = string(body)
+		return nil
+	}
+
+	if contentType == "" {
+		contentType = JSONCodec{}.ContentType()
+	}
+	codec, ok := CodecFor(contentType)
+	if !ok {
+		return fmt.Errorf("no codec registered for content type %q", contentType)
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	return codec.Unmarshal(body, out)
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type User struct {
+	XMLName xml.Name `json:"-" yaml:"-" xml:"user"`
+	Name    string   `json:"name" yaml:"name" xml:"name"`
+	Age     int      `json:"age" yaml:"age" xml:"age"`
+}
+
+func TestDecodeByContentType(t *testing.T) {
+	bodies := map[string]string{
+		"application/json":                  `{"name":"root","age":3}`,
+		"application/problem+json":          `{"name":"root","age":3}`,
+		"application/xml; charset=utf-8":    `<user><name>root</name><age>3</age></user>`,
+		"application/yaml":                  "name: root\nage: 3\n",
+		"application/vnd.api+json":          `{"name":"root","age":3}`,
+		"application/json; charset=utf-8":   `{"name":"root","age":3}`,
+		"text/yaml":                         "name: root\nage: 3\n",
+		"application/x-yaml; charset=utf-8": "name: root\nage: 3\n",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.URL.Query().Get("type")
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write([]byte(bodies[contentType]))
+	}))
+	defer server.Close()
+
+	for contentType := range bodies {
+		t.Run(contentType, func(t *testing.T) {
+			user := User{}
+			err := New(server.URL).AddQuery("type", contentType).Get().Into(&user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Name != "root" || user.Age != 3 {
+				t.Fatalf("got %+v", user)
+			}
+		})
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte(r.Header.Get("Content-Type") + " " + string(body)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		req  *request
+		want string
+	}{
+		{"json", New(server.URL).JSON(User{Name: "root"}).Post(nil), `application/json {"name":"root","age":0}`},
+		{"xml", New(server.URL).XML(User{Name: "root"}).Put(nil), `application/xml <user><name>root</name><age>0</age></user>`},
+		{"yaml", New(server.URL).Post(nil).YAML(User{Name: "root"}), "application/yaml name: root\nage: 0\n"},
+		{"form", New(server.URL).Encode("application/x-www-form-urlencoded", map[string]string{"a": "1"}).Post(nil), "application/x-www-form-urlencoded a=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			if err := tt.req.Do(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string { return "text/x-upper" }
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]string) = strings.Fields(string(data))
+	return nil
+}
+
+func TestRegisterCodec(t *testing.T) {
+	RegisterCodec(upperCodec{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	var words []string
+	if err := New(server.URL).Encode("text/x-upper", "a b").Post(nil).Do(&words); err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0] != "A" || words[1] != "B" {
+		t.Fatalf("got %q", words)
+	}
+
+	if err := New(server.URL).Encode("text/unknown", "a").Post(nil).Do(nil); err == nil {
+		t.Fatal("expected an error for an unregistered content type")
+	}
+}
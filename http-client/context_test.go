@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// stallServer answers after the headers with a body that never ends, or
+// never answers at all for /hang, until the test finishes.
+func stallServer(t *testing.T) *httptest.Server {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hang" {
+			_, _ = w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		server.Close()
+	})
+	return server
+}
+
+func TestContextCancel(t *testing.T) {
+	server := stallServer(t)
+
+	for _, path := range []string{"/hang", "/body"} {
+		t.Run(path, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(50*time.Millisecond, cancel)
+
+			start := time.Now()
+			err := New(server.URL + path).WithContext(ctx).Get().Do(nil)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("got %v, want %v", err, context.Canceled)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Fatalf("cancel took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server := stallServer(t)
+
+	err := New(server.URL + "/body").SetTimeout(50 * time.Millisecond).Get().Do(nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	c := NewClient(server.URL, WithTimeout(50*time.Millisecond))
+	if err := c.New("/hang").Get().Do(nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestResponseHeaderTimeout(t *testing.T) {
+	server := stallServer(t)
+
+	c := NewClient(server.URL, WithResponseHeaderTimeout(50*time.Millisecond))
+	start := time.Now()
+	if err := c.New("/hang").Get().Do(nil); err == nil {
+		t.Fatal("expected a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("timeout took %s", elapsed)
+	}
+}
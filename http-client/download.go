@@ -0,0 +1,384 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type downloadSetting struct {
+	resume bool
+	chunks int
+	sha256 string
+}
+
+type DownloadSettingFunc func(*downloadSetting)
+
+// DownloadResume keeps the partial file of a failed download and continues it
+// with a Range request on the next call.
+func DownloadResume() DownloadSettingFunc {
+	return func(s *downloadSetting) {
+		s.resume = true
+	}
+}
+
+// DownloadChunks downloads n ranges of the file in parallel when the server
+// supports Range requests, and sequentially otherwise. A failed chunked
+// download is not kept for resuming, and a partial file left by a sequential
+// one is resumed sequentially.
+func DownloadChunks(n int) DownloadSettingFunc {
+	return func(s *downloadSetting) {
+		s.chunks = n
+	}
+}
+
+// DownloadSHA256 verifies the hex encoded SHA-256 checksum of the file before
+// it is moved into place.
+func DownloadSHA256(sum string) DownloadSettingFunc {
+	return func(s *downloadSetting) {
+		s.sha256 = strings.ToLower(sum)
+	}
+}
+
+// ChecksumError is returned when a downloaded file does not match the
+// expected checksum.
+type ChecksumError struct {
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected sha256 %s, got %s", e.Expected, e.Actual)
+}
+
+// OnDownloadProgress sets a callback reporting how much of the response body
+// was written by DownloadTo or DownloadFile. The total is -1 when unknown, and
+// a resumed download starts from the size of the partial file.
+func (r *request) OnDownloadProgress(fn ProgressFunc) *request {
+	r.downloadProgress = fn
+	return r
+}
+
+// DownloadTo streams the response body to w without buffering it and returns
+// the number of bytes written. Responses with a status of 400 or more are
+// returned as an *HTTPError.
+func (r *request) DownloadTo(w io.Writer) (int64, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	start := time.Now()
+	resp, err := r.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := r.checkStatus(resp, start); err != nil {
+		return 0, err
+	}
+	return io.Copy(w, r.progressBody(resp.Body, 0, resp.ContentLength))
+}
+
+// DownloadFile streams the response body to path. The file is written to
+// path+".part" and renamed to path once it is complete and its checksum
+// verified, so path never holds a partial file.
+func (r *request) DownloadFile(path string, opts ...DownloadSettingFunc) error {
+	setting := &downloadSetting{}
+	for _, opt := range opts {
+		opt(setting)
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+
+	tmp := path + ".part"
+	if !setting.resume {
+		_ = os.Remove(tmp)
+	}
+
+	var err error
+	if _, statErr := os.Stat(tmp); setting.chunks > 1 && statErr != nil {
+		err = r.downloadChunks(ctx, tmp, setting)
+	} else {
+		err = r.downloadSequential(ctx, tmp, setting)
+	}
+	if err == nil && setting.sha256 != "" {
+		err = verifySHA256(tmp, setting.sha256)
+		if err != nil {
+			// the partial file is complete, resuming it cannot fix it
+			_ = os.Remove(tmp)
+			return err
+		}
+	}
+	if err != nil {
+		if !setting.resume {
+			_ = os.Remove(tmp)
+		}
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (r *request) downloadSequential(ctx context.Context, tmp string, setting *downloadSetting) error {
+	var offset int64
+	if info, err := os.Stat(tmp); err == nil {
+		offset = info.Size()
+	}
+
+	req := r.Clone()
+	if offset > 0 {
+		req.headers.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	sentAt := time.Now()
+	resp, err := req.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, ok := contentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("unexpected Content-Range %q for offset %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags |= os.O_APPEND
+		total = size
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the partial file may already hold the whole content
+		if size, ok := unsatisfiedRange(resp.Header.Get("Content-Range")); ok && size == offset {
+			return nil
+		}
+		return req.checkStatus(resp, sentAt)
+	case resp.StatusCode >= 400:
+		return req.checkStatus(resp, sentAt)
+	default:
+		// the server ignored the range, start over
+		flags |= os.O_TRUNC
+		offset = 0
+	}
+
+	f, err := os.OpenFile(tmp, flags, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r.progressBody(resp.Body, offset, total))
+	return closeFile(f, err)
+}
+
+func (r *request) downloadChunks(ctx context.Context, tmp string, setting *downloadSetting) error {
+	size, ok, err := r.probeSize(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok || size < int64(setting.chunks) {
+		return r.downloadSequential(ctx, tmp, setting)
+	}
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(size); err != nil {
+		return closeFile(f, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		done     int64
+	)
+	chunk := size / int64(setting.chunks)
+	for i := 0; i < setting.chunks; i++ {
+		start, end := int64(i)*chunk, int64(i+1)*chunk-1
+		if i == setting.chunks-1 {
+			end = size - 1
+		}
+
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			err := r.downloadRange(ctx, f, start, end, func(n int64) {
+				mu.Lock()
+				defer mu.Unlock()
+				done += n
+				if r.downloadProgress != nil {
+					r.downloadProgress(done, size)
+				}
+			})
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	if err := closeFile(f, firstErr); err != nil {
+		// the holes of the file cannot be told from content, so it cannot
+		// be resumed
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// probeSize asks for the first byte to learn whether the server supports
+// ranges and the size of the content.
+func (r *request) probeSize(ctx context.Context) (int64, bool, error) {
+	req := r.Clone()
+	req.headers.Set("Range", "bytes=0-0")
+	sentAt := time.Now()
+	resp, err := req.open(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+		return 0, false, req.checkStatus(resp, sentAt)
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, false, nil
+	}
+	_, size, ok := contentRange(resp.Header.Get("Content-Range"))
+	return size, ok && size >= 0, nil
+}
+
+func (r *request) downloadRange(ctx context.Context, f *os.File, start, end int64, progress func(n int64)) error {
+	req := r.Clone()
+	req.headers.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	sentAt := time.Now()
+	resp, err := req.open(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		if err := req.checkStatus(resp, sentAt); err != nil {
+			return err
+		}
+		return fmt.Errorf("range %d-%d: unexpected status %d", start, end, resp.StatusCode)
+	}
+
+	buf := make([]byte, 32<<10)
+	offset := start
+	for offset <= end {
+		n, err := resp.Body.Read(buf)
+		if int64(n) > end-offset+1 {
+			n = int(end - offset + 1)
+		}
+		if n > 0 {
+			if _, werr := f.WriteAt(buf[:n], offset); werr != nil {
+				return werr
+			}
+			offset += int64(n)
+			progress(int64(n))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if offset != end+1 {
+		return fmt.Errorf("range %d-%d: %w", start, end, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// contentRange parses "bytes start-end/size"; size is -1 for "*".
+func contentRange(value string) (start, size int64, ok bool) {
+	if !strings.HasPrefix(value, "bytes ") {
+		return 0, 0, false
+	}
+	value = strings.TrimPrefix(value, "bytes ")
+	i, j := strings.IndexByte(value, '-'), strings.IndexByte(value, '/')
+	if i < 0 || j < i {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(value[:i], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if value[j+1:] == "*" {
+		return start, -1, true
+	}
+	size, err = strconv.ParseInt(value[j+1:], 10, 64)
+	return start, size, err == nil
+}
+
+// unsatisfiedRange parses the "bytes */size" of a 416 response.
+func unsatisfiedRange(value string) (int64, bool) {
+	if !strings.HasPrefix(value, "bytes */") {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimPrefix(value, "bytes */"), 10, 64)
+	return size, err == nil
+}
+
+func (r *request) progressBody(body io.ReadCloser, offset, total int64) io.Reader {
+	if r.downloadProgress == nil {
+		return body
+	}
+	return &progressReader{ReadCloser: body, sent: offset, total: total, fn: r.downloadProgress}
+}
+
+// checkStatus returns an *HTTPError for statuses of 400 or more, reading at
+// most 64KB of the body.
+func (r *request) checkStatus(resp *http.Response, start time.Time) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	response, err := r.readResponse(resp, start, 64<<10)
+	if err != nil {
+		return err
+	}
+	return r.httpError(response)
+}
+
+func closeFile(f *os.File, err error) error {
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func verifySHA256(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return &ChecksumError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
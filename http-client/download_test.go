@@ -0,0 +1,189 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// artifactServer serves content with Range support and records the Range
+// header of every request. failAfter, when positive, cuts the first full
+// response after that many bytes.
+func artifactServer(t *testing.T, content []byte, failAfter int) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		cut := failAfter > 0 && r.Header.Get("Range") == ""
+		failAfter = 0
+		mu.Unlock()
+
+		if cut {
+			w.Header().Set("Content-Length", "999999")
+			_, _ = w.Write(content[:10])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "image.iso", time.Time{}, bytes.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), ranges...)
+	}
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDownloadTo(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	server, _ := artifactServer(t, content, 0)
+
+	var buf bytes.Buffer
+	var done, total int64
+	n, err := New(server.URL).OnDownloadProgress(func(d, tt int64) { done, total = d, tt }).DownloadTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(content)) || !bytes.Equal(buf.Bytes(), content) {
+		t.Fatalf("got %d bytes", n)
+	}
+	if done != n || total != n {
+		t.Fatalf("progress %d/%d, want %d", done, total, n)
+	}
+
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+	if _, err := New(missing.URL).DownloadTo(&buf); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want a 404 HTTPError", err)
+	}
+}
+
+func TestDownloadFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefgh"), 4096)
+	server, ranges := artifactServer(t, content, 10)
+	path := filepath.Join(t.TempDir(), "image.iso")
+
+	err := New(server.URL).DownloadFile(path, DownloadResume())
+	if err == nil {
+		t.Fatal("expected the cut download to fail")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("a failed download must not create the file")
+	}
+	if part, _ := ioutil.ReadFile(path + ".part"); !bytes.Equal(part, content[:10]) {
+		t.Fatalf("got partial file %q", part)
+	}
+
+	var done []int64
+	err = New(server.URL).
+		OnDownloadProgress(func(d, _ int64) { done = append(done, d) }).
+		DownloadFile(path, DownloadResume(), DownloadSHA256(sha256Hex(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadFile(path)
+	if !bytes.Equal(got, content) {
+		t.Fatal("resumed file differs from the content")
+	}
+	if r := ranges(); r[len(r)-1] != "bytes=10-" {
+		t.Fatalf("got ranges %q", r)
+	}
+	if done[0] <= 10 || done[len(done)-1] != int64(len(content)) {
+		t.Fatalf("progress %v must continue from the partial file", done)
+	}
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Fatal("the partial file must be renamed")
+	}
+}
+
+func TestDownloadFileCompletePart(t *testing.T) {
+	content := []byte("complete")
+	server, _ := artifactServer(t, content, 0)
+	path := filepath.Join(t.TempDir(), "image.iso")
+	if err := ioutil.WriteFile(path+".part", content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := New(server.URL).DownloadFile(path, DownloadResume()); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, content) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDownloadFileChunks(t *testing.T) {
+	content := []byte(strings.Repeat("the quick brown fox ", 5000))
+	server, ranges := artifactServer(t, content, 0)
+	path := filepath.Join(t.TempDir(), "image.iso")
+
+	var mu sync.Mutex
+	var done, total int64
+	err := New(server.URL).
+		OnDownloadProgress(func(d, tt int64) {
+			mu.Lock()
+			done, total = d, tt
+			mu.Unlock()
+		}).
+		DownloadFile(path, DownloadChunks(4), DownloadSHA256(strings.ToUpper(sha256Hex(content))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, content) {
+		t.Fatal("chunked file differs from the content")
+	}
+	if r := ranges(); len(r) != 5 || r[0] != "bytes=0-0" {
+		t.Fatalf("got ranges %q, want a probe and 4 chunks", r)
+	}
+	if done != int64(len(content)) || total != done {
+		t.Fatalf("progress %d/%d", done, total)
+	}
+}
+
+func TestDownloadFileChunksWithoutRanges(t *testing.T) {
+	content := []byte("no ranges here")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "image.iso")
+
+	if err := New(server.URL).DownloadFile(path, DownloadChunks(4)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ioutil.ReadFile(path); !bytes.Equal(got, content) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestDownloadFileChecksum(t *testing.T) {
+	server, _ := artifactServer(t, []byte("tampered"), 0)
+	path := filepath.Join(t.TempDir(), "image.iso")
+
+	err := New(server.URL).DownloadFile(path, DownloadResume(), DownloadSHA256(sha256Hex([]byte("original"))))
+	var checksum *ChecksumError
+	if !errors.As(err, &checksum) || checksum.Actual != sha256Hex([]byte("tampered")) {
+		t.Fatalf("got %v, want a ChecksumError", err)
+	}
+	for _, p := range []string{path, path + ".part"} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Fatalf("%s must be removed after a checksum mismatch", p)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PreHandler processes the raw body of a successful response before it is
+// decoded, e.g. to unwrap an envelope or decrypt it.
+type PreHandler interface {
+	PreHandler(body []byte) ([]byte, error)
+}
+
+// PreHandlerFunc adapts a function to a PreHandler.
+type PreHandlerFunc func(body []byte) ([]byte, error)
+
+func (f PreHandlerFunc) PreHandler(body []byte) ([]byte, error) {
+	return f(body)
+}
+
+// PostHandler processes the value a response was decoded into, e.g. to
+// validate it.
+type PostHandler interface {
+	PostHandler(v interface{}) error
+}
+
+// PostHandlerFunc adapts a function to a PostHandler.
+type PostHandlerFunc func(v interface{}) error
+
+func (f PostHandlerFunc) PostHandler(v interface{}) error {
+	return f(v)
+}
+
+// WithPreHandler adds a pre-handler run for every request of the client,
+// before the pre-handlers of the request.
+func WithPreHandler(h PreHandler) ClientSettingFunc {
+	return func(c *Client) {
+		c.preHandlers = append(c.preHandlers, h)
+	}
+}
+
+// WithPostHandler adds a post-handler run for every request of the client,
+// before the post-handlers of the request.
+func WithPostHandler(h PostHandler) ClientSettingFunc {
+	return func(c *Client) {
+		c.postHandlers = append(c.postHandlers, h)
+	}
+}
+
+// AddPreHandler adds a pre-handler. Pre-handlers run in the order they were
+// added, each receiving the body returned by the previous one.
+func (r *request) AddPreHandler(h PreHandler) *request {
+	r.preHandlers = append(r.preHandlers, h)
+	return r
+}
+
+// AddPostHandler adds a post-handler. Post-handlers run in the order they
+// were added, after the body was decoded.
+func (r *request) AddPostHandler(h PostHandler) *request {
+	r.postHandlers = append(r.postHandlers, h)
+	return r
+}
+
+// handle runs the response pipeline: pre-handlers on the raw body, decoding
+// into out, then post-handlers on out.
+func (r *request) handle(contentType string, body []byte, out interface{}) error {
+	var err error
+	for _, handlers := range [][]PreHandler{r.client.preHandlers, r.preHandlers} {
+		for _, h := range handlers {
+			if body, err = h.PreHandler(body); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = decode(contentType, body, out); err != nil {
+		return err
+	}
+
+	for _, handlers := range [][]PostHandler{r.client.postHandlers, r.postHandlers} {
+		for _, h := range handlers {
+			if err = h.PostHandler(out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HttpResponse is the {Code, Msg, Data} envelope used by our services. As a
+// PreHandler it returns Data, or a *BusinessError when Code is not zero.
+//
+//	err := client.New(url).Get().AddPreHandler(&client.HttpResponse{}).Do(&user)
+type HttpResponse struct {
+	Code int
+	Msg  string
+	Data json.RawMessage
+}
+
+func (r HttpResponse) PreHandler(body []byte) ([]byte, error) {
+	envelope := HttpResponse{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("decode response envelope: %w", err)
+	}
+	if envelope.Code != 0 {
+		return nil, &BusinessError{Code: envelope.Code, Msg: envelope.Msg, Data: envelope.Data}
+	}
+	return envelope.Data, nil
+}
+
+// BusinessError is returned when an envelope carries a non-zero code.
+type BusinessError struct {
+	Code int
+	Msg  string
+	Data json.RawMessage
+}
+
+func (e *BusinessError) Error() string {
+	return fmt.Sprintf("business error %d: %s", e.Code, e.Msg)
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func envelopeServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/missing" {
+			_, _ = w.Write([]byte(`{"code":404001,"msg":"user not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":{"name":"root","age":3}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEnvelope(t *testing.T) {
+	server := envelopeServer(t)
+
+	user := User{}
+	if err := New(server.URL + "/user").AddPreHandler(&HttpResponse{}).Get().Do(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "root" || user.Age != 3 {
+		t.Fatalf("got %+v", user)
+	}
+
+	err := New(server.URL + "/missing").Get().AddPreHandler(HttpResponse{}).Do(&user)
+	var be *BusinessError
+	if !errors.As(err, &be) || be.Code != 404001 || be.Msg != "user not found" {
+		t.Fatalf("expected a business error, got %v", err)
+	}
+}
+
+func TestHandlerOrder(t *testing.T) {
+	server := envelopeServer(t)
+
+	var order []string
+	pre := func(name string) PreHandler {
+		return PreHandlerFunc(func(body []byte) ([]byte, error) {
+			order = append(order, name)
+			return body, nil
+		})
+	}
+	post := func(name string) PostHandler {
+		return PostHandlerFunc(func(v interface{}) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	c := NewClient(server.URL, WithPreHandler(pre("client pre")), WithPostHandler(post("client post")))
+	user := User{}
+	err := c.New("/user").
+		AddPostHandler(post("request post")).
+		AddPreHandler(pre("request pre")).
+		AddPreHandler(&HttpResponse{}).
+		Get().
+		Do(&user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "client pre, request pre, client post, request post"
+	if got := strings.Join(order, ", "); got != want {
+		t.Fatalf("got order %q, want %q", got, want)
+	}
+	if user.Name != "root" {
+		t.Fatalf("got %+v", user)
+	}
+}
+
+func TestPostHandlerError(t *testing.T) {
+	server := envelopeServer(t)
+
+	errRoot := errors.New("root is reserved")
+	err := New(server.URL + "/user").
+		AddPreHandler(&HttpResponse{}).
+		AddPostHandler(PostHandlerFunc(func(v interface{}) error {
+			if v.(*User).Name == "root" {
+				return errRoot
+			}
+			return nil
+		})).
+		Get().
+		Do(&User{})
+	if !errors.Is(err, errRoot) {
+		t.Fatalf("got %v, want %v", err, errRoot)
+	}
+}
@@ -0,0 +1,255 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the requests of a Client. Waiting for a token or a free
+// slot respects the request context, and a request whose deadline would pass
+// before its token is available fails at once.
+//
+// The limits are shared by all requests of the client, or by the requests to
+// each host when PerHost is set, or only apply to Host when it is set. Every
+// attempt of a retried request counts.
+type RateLimit struct {
+	// Rate is the number of requests per second, 0 for no rate limit.
+	Rate float64
+	// Burst is the number of requests that may be sent at once after being
+	// idle, 1 when not set.
+	Burst int
+	// MaxInFlight limits the requests sent but whose body was not closed
+	// yet, 0 for no limit.
+	MaxInFlight int
+
+	PerHost bool
+	Host    string
+
+	// Adaptive halves the rate when a response is a 429 or its
+	// X-RateLimit-Remaining header runs low, down to Rate/16, and restores it
+	// step by step while responses show no pressure.
+	Adaptive bool
+}
+
+// WithRateLimit adds limits to every request of the client. It may be used
+// several times, e.g. for a limit of the client and a stricter one per host;
+// a request waits for all of them.
+func WithRateLimit(limit RateLimit) ClientSettingFunc {
+	return func(c *Client) {
+		c.limiters = append(c.limiters, &limiter{config: limit, hosts: map[string]*limits{}})
+	}
+}
+
+// ErrRateLimited is returned when a request could not get a token before its
+// context deadline.
+var ErrRateLimited = fmt.Errorf("rate limit: %w", context.DeadlineExceeded)
+
+type limiter struct {
+	config RateLimit
+
+	mu    sync.Mutex
+	hosts map[string]*limits
+}
+
+// limits are the token bucket and in-flight slots of one key.
+type limits struct {
+	bucket   *bucket
+	inFlight chan struct{}
+}
+
+func (l *limiter) limits(host string) *limits {
+	key := ""
+	if l.config.PerHost {
+		key = host
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lim, ok := l.hosts[key]
+	if !ok {
+		lim = &limits{}
+		if l.config.Rate > 0 {
+			lim.bucket = newBucket(l.config.Rate, l.config.Burst)
+		}
+		if l.config.MaxInFlight > 0 {
+			lim.inFlight = make(chan struct{}, l.config.MaxInFlight)
+		}
+		l.hosts[key] = lim
+	}
+	return lim
+}
+
+func (l *limiter) middleware(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if l.config.Host != "" && l.config.Host != req.URL.Host && l.config.Host != req.URL.Hostname() {
+			return next(req)
+		}
+		lim := l.limits(req.URL.Host)
+		ctx := req.Context()
+
+		if lim.inFlight != nil {
+			select {
+			case lim.inFlight <- struct{}{}:
+			case <-ctx.Done():
+				closeBody(req)
+				return nil, ctx.Err()
+			}
+		}
+		release := func() {
+			if lim.inFlight != nil {
+				<-lim.inFlight
+			}
+		}
+
+		if lim.bucket != nil {
+			if err := lim.bucket.wait(ctx); err != nil {
+				release()
+				closeBody(req)
+				return nil, err
+			}
+		}
+
+		resp, err := next(req)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		if l.config.Adaptive && lim.bucket != nil {
+			lim.bucket.adapt(underPressure(resp))
+		}
+		if lim.inFlight != nil {
+			resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		}
+		return resp, nil
+	}
+}
+
+// closeBody closes the body of a request that will not be sent, as the
+// transport would have.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// underPressure reports whether resp asks the client to slow down: a 429, or
+// X-RateLimit-Remaining at zero or below a tenth of X-RateLimit-Limit.
+func underPressure(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return false
+	}
+	limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	if err != nil || limit <= 0 {
+		return remaining <= 0
+	}
+	return remaining*10 < limit
+}
+
+// releaseBody frees an in-flight slot when the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// bucket is a token bucket. Tokens are reserved in arrival order: a request
+// takes a token even if the bucket is empty and waits until it is refilled.
+type bucket struct {
+	mu      sync.Mutex
+	rate    float64
+	maxRate float64
+	burst   float64
+	tokens  float64
+	last    time.Time
+}
+
+func newBucket(rate float64, burst int) *bucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &bucket{
+		rate:    rate,
+		maxRate: rate,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+	}
+}
+
+// advance refills the bucket up to now.
+func (b *bucket) advance(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+func (b *bucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.advance(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		b.tokens++
+		b.mu.Unlock()
+		return ErrRateLimited
+	}
+	b.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// give the token back to the requests queued behind
+		b.mu.Lock()
+		b.advance(time.Now())
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// adapt halves the rate under pressure, emptying the bucket, and otherwise
+// raises it by a sixteenth of the configured rate.
+func (b *bucket) adapt(pressure bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(time.Now())
+	if pressure {
+		b.rate /= 2
+		if min := b.maxRate / 16; b.rate < min {
+			b.rate = min
+		}
+		if b.tokens > 0 {
+			b.tokens = 0
+		}
+		return
+	}
+	b.rate += b.maxRate / 16
+	if b.rate > b.maxRate {
+		b.rate = b.maxRate
+	}
+}
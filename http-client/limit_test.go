@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyServer holds every request for delay and records the highest
+// number of requests served at once.
+func concurrencyServer(t *testing.T, delay time.Duration) (*httptest.Server, *int32) {
+	var current, max int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			m := atomic.LoadInt32(&max)
+			if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+	}))
+	t.Cleanup(server.Close)
+	return server, &max
+}
+
+func TestRateLimit(t *testing.T) {
+	server, _ := concurrencyServer(t, 0)
+	c := NewClient(server.URL, WithRateLimit(RateLimit{Rate: 20}))
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := c.New("/").Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Fatalf("5 requests at 20/s took %s, want about 200ms", elapsed)
+	}
+}
+
+func TestRateLimitContext(t *testing.T) {
+	server, _ := concurrencyServer(t, 0)
+	c := NewClient(server.URL, WithRateLimit(RateLimit{Rate: 1, Burst: 1}))
+	if err := c.New("/").Get().Do(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := c.New("/").SetTimeout(100 * time.Millisecond).Get().Do(nil)
+	if !errors.Is(err, ErrRateLimited) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("a request that cannot get a token in time waited %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := c.New("/").WithContext(ctx).Get().Do(nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+}
+
+func TestRateLimitClosesBody(t *testing.T) {
+	limited := func(limit RateLimit) *closeCounter {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		var counter closeCounter
+		body, _, _ := counter.open()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://127.0.0.1:1", body)
+		l := &limiter{config: limit, hosts: map[string]*limits{}}
+		l.limits("").inFlight <- struct{}{}
+		if b := l.limits("").bucket; b != nil {
+			b.tokens = 0
+		}
+
+		send := l.middleware(func(req *http.Request) (*http.Response, error) {
+			t.Fatal("a limited request was sent")
+			return nil, nil
+		})
+		if _, err := send(req); err == nil {
+			t.Fatal("expected the limiter to fail the request")
+		}
+		return &counter
+	}
+
+	if counter := limited(RateLimit{MaxInFlight: 1}); counter.closed != 1 {
+		t.Fatalf("waiting for a slot: body closed %d times, want once", counter.closed)
+	}
+	if counter := limited(RateLimit{MaxInFlight: 2, Rate: 1}); counter.closed != 1 {
+		t.Fatalf("waiting for a token: body closed %d times, want once", counter.closed)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	server, max := concurrencyServer(t, 20*time.Millisecond)
+	other, otherMax := concurrencyServer(t, 20*time.Millisecond)
+	c := NewClient("",
+		WithRateLimit(RateLimit{MaxInFlight: 3}),
+		WithRateLimit(RateLimit{MaxInFlight: 1, Host: strings.TrimPrefix(other.URL, "http://")}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, u := range []string{server.URL, other.URL} {
+			wg.Add(1)
+			go func(u string) {
+				defer wg.Done()
+				if err := c.New(u).Get().Do(nil); err != nil {
+					t.Error(err)
+				}
+			}(u)
+		}
+	}
+	wg.Wait()
+	if *max > 3 || *otherMax != 1 {
+		t.Fatalf("got %d and %d requests at once, want at most 3 and 1", *max, *otherMax)
+	}
+}
+
+func TestPerHostLimit(t *testing.T) {
+	a, _ := concurrencyServer(t, 0)
+	b, _ := concurrencyServer(t, 0)
+	c := NewClient("", WithRateLimit(RateLimit{Rate: 10, PerHost: true}))
+
+	start := time.Now()
+	for _, u := range []string{a.URL, b.URL} {
+		if err := c.New(u).Get().Do(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("hosts share a bucket, took %s", elapsed)
+	}
+}
+
+func TestAdaptiveRateLimit(t *testing.T) {
+	var remaining atomic.Value
+	remaining.Store("100")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", remaining.Load().(string))
+		if r.URL.Path == "/throttled" {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, WithRateLimit(RateLimit{Rate: 1000, Burst: 10, Adaptive: true}))
+	rate := func() float64 {
+		b := c.limiters[0].limits("").bucket
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.rate
+	}
+
+	_ = c.New("/throttled").Get().Do(nil)
+	if got := rate(); got != 500 {
+		t.Fatalf("got rate %v after a 429, want 500", got)
+	}
+	remaining.Store("5")
+	_ = c.New("/").Get().Do(nil)
+	if got := rate(); got != 250 {
+		t.Fatalf("got rate %v with 5 of 100 remaining, want 250", got)
+	}
+	for i := 0; i < 5; i++ {
+		_ = c.New("/throttled").Get().Do(nil)
+	}
+	if got := rate(); got != 1000.0/16 {
+		t.Fatalf("got rate %v, want the floor of Rate/16", got)
+	}
+
+	remaining.Store("80")
+	_ = c.New("/").Get().Do(nil)
+	if got := rate(); got != 1000.0/16*2 {
+		t.Fatalf("got rate %v, want it raised by Rate/16", got)
+	}
+}
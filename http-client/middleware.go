@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RoundTripFunc sends one request. It implements http.RoundTripper.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the sending of a request. Middlewares run for every
+// attempt, so a retried request passes through them again.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// RoundTripper adapts an http.RoundTripper decorator, such as one from
+// another library, to a Middleware.
+func RoundTripper(wrap func(next http.RoundTripper) http.RoundTripper) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return wrap(next).RoundTrip
+	}
+}
+
+// WithMiddleware adds middlewares to every request of the client.
+func WithMiddleware(mw ...Middleware) ClientSettingFunc {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, mw...)
+	}
+}
+
+// Use adds middlewares to the request.
+//
+// Middlewares are called in the order they were added, client middlewares
+// before request middlewares: the first one sees the request first and the
+// response last.
+func (r *request) Use(mw ...Middleware) *request {
+	r.middlewares = append(r.middlewares, mw...)
+	return r
+}
+
+// roundTrip chains the middlewares of the client and the request, the
+// authenticator, then the rate limits, around the http.Client.
+func (r *request) roundTrip(client *http.Client) RoundTripFunc {
+	rt := RoundTripFunc(client.Do)
+	for i := len(r.client.limiters) - 1; i >= 0; i-- {
+		rt = r.client.limiters[i].middleware(rt)
+	}
+	auth := r.auth
+	if auth == nil {
+		auth = r.client.auth
+	}
+	if auth != nil {
+		rt = authenticate(auth)(rt)
+	}
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		rt = r.middlewares[i](rt)
+	}
+	for i := len(r.client.middlewares) - 1; i >= 0; i-- {
+		rt = r.client.middlewares[i](rt)
+	}
+	return rt
+}
+
+// UserAgent sets the User-Agent header.
+func UserAgent(ua string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", ua)
+			return next(req)
+		}
+	}
+}
+
+// RequestID sets header to a random id unless the request already has one.
+// The id is kept across retries.
+func RequestID(header string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(header) == "" {
+				b := make([]byte, 16)
+				if _, err := rand.Read(b); err != nil {
+					return nil, err
+				}
+				req.Header.Set(header, hex.EncodeToString(b))
+			}
+			return next(req)
+		}
+	}
+}
+
+type headersKey struct{}
+
+// ContextWithHeaders stores the headers of an incoming request in ctx, to be
+// forwarded by PropagateHeaders.
+func ContextWithHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, headersKey{}, h)
+}
+
+// PropagateHeaders copies the given headers from the headers stored with
+// ContextWithHeaders in the request context, e.g. trace or tenant ids.
+// Headers already set on the request are kept.
+func PropagateHeaders(keys ...string) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			if h, ok := req.Context().Value(headersKey{}).(http.Header); ok {
+				for _, key := range keys {
+					if values := h.Values(key); len(values) > 0 && req.Header.Get(key) == "" {
+						req.Header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+					}
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// Logger logs the method, url, status and duration of every attempt. The
+// password of the url is redacted and the query left out, since it may hold
+// tokens or signatures.
+func Logger(logf func(format string, args ...interface{})) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil {
+				cause := err
+				if urlErr, ok := err.(*url.Error); ok {
+					// the url of the error still holds the query
+					cause = urlErr.Err
+				}
+				logf("%s %s: %v (%s)", req.Method, logURL(req.URL), cause, time.Since(start))
+				return resp, err
+			}
+			logf("%s %s: %d (%s)", req.Method, logURL(req.URL), resp.StatusCode, time.Since(start))
+			return resp, err
+		}
+	}
+}
+
+func logURL(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery, redacted.ForceQuery = "", false
+	return redacted.Redacted()
+}
+
+// Metrics calls observe after every attempt, e.g. to record a latency
+// histogram. resp is nil when err is not.
+func Metrics(observe func(req *http.Request, resp *http.Response, err error, d time.Duration)) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next(req)
+			observe(req, resp, err, time.Since(start))
+			return resp, err
+		}
+	}
+}
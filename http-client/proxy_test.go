@@ -0,0 +1,269 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// proxyLog records what a test proxy saw.
+type proxyLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *proxyLog) add(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func (l *proxyLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.lines, "\n")
+}
+
+func pipe(a, b net.Conn) {
+	go func() {
+		_, _ = io.Copy(a, b)
+		_ = a.Close()
+	}()
+	_, _ = io.Copy(b, a)
+	_ = b.Close()
+}
+
+// httpProxy forwards plain requests and tunnels CONNECT requests, answering
+// 407 unless the credentials are user:secret.
+func httpProxy(t *testing.T, log *proxyLog) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "Basic dXNlcjpzZWNyZXQ=" {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		log.add("%s %s", r.Method, r.RequestURI)
+
+		if r.Method == http.MethodConnect {
+			upstream, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			pipe(conn, upstream)
+			return
+		}
+
+		r.RequestURI = ""
+		r.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// socks5Proxy is a minimal RFC 1928 server with username/password
+// authentication (RFC 1929) for user:secret.
+func socks5Proxy(t *testing.T, log *proxyLog) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				if err := serveSOCKS5(conn, log); err != nil {
+					log.add("error: %v", err)
+					_ = conn.Close()
+				}
+			}()
+		}
+	}()
+	return listener
+}
+
+func serveSOCKS5(conn net.Conn, log *proxyLog) error {
+	r := bufio.NewReader(conn)
+	read := func(n int) []byte {
+		b := make([]byte, n)
+		_, _ = io.ReadFull(r, b)
+		return b
+	}
+
+	greeting := read(2)
+	if greeting[0] != 5 || !strings.ContainsRune(string(read(int(greeting[1]))), 2) {
+		_, _ = conn.Write([]byte{5, 0xff})
+		return fmt.Errorf("username/password authentication not offered")
+	}
+	_, _ = conn.Write([]byte{5, 2})
+
+	read(1)
+	user := string(read(int(read(1)[0])))
+	password := string(read(int(read(1)[0])))
+	if user != "user" || password != "secret" {
+		_, _ = conn.Write([]byte{1, 1})
+		return fmt.Errorf("bad credentials %s:%s", user, password)
+	}
+	_, _ = conn.Write([]byte{1, 0})
+
+	request := read(4)
+	var host string
+	switch request[3] {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	case 4:
+		host = net.IP(read(16)).String()
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(read(2)))))
+	log.add("CONNECT %s", addr)
+
+	upstream, err := net.Dial("tcp", addr)
+	if err != nil {
+		_, _ = conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return err
+	}
+	_, _ = conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	pipe(&bufferedConn{Conn: conn, r: r}, upstream)
+	return nil
+}
+
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func helloServer(t *testing.T, tls bool) *httptest.Server {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	})
+	server := httptest.NewUnstartedServer(handler)
+	if tls {
+		server.StartTLS()
+	} else {
+		server.Start()
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPProxy(t *testing.T) {
+	log := &proxyLog{}
+	proxy := httpProxy(t, log)
+	proxyURL := strings.Replace(proxy.URL, "http://", "http://user:secret@", 1)
+	target := helloServer(t, false)
+	secure := helloServer(t, true)
+
+	var body string
+	if err := NewClient(target.URL, WithProxy(proxyURL)).New("/plain").Get().Do(&body); err != nil || body != "hello" {
+		t.Fatalf("got %q, %v", body, err)
+	}
+
+	c := NewClient(secure.URL, WithProxy(proxyURL), WithTLS(secure.Client().Transport.(*http.Transport).TLSClientConfig))
+	if err := c.New("/tunneled").Get().Do(&body); err != nil || body != "hello" {
+		t.Fatalf("got %q, %v", body, err)
+	}
+
+	want := "GET " + target.URL + "/plain\nCONNECT " + strings.TrimPrefix(secure.URL, "https://")
+	if log.String() != want {
+		t.Fatalf("proxy saw\n%s\nwant\n%s", log, want)
+	}
+
+	err := NewClient(target.URL, WithProxy(proxy.URL)).New("/").Get().Do(nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusProxyAuthRequired {
+		t.Fatalf("got %v, want a 407 without credentials", err)
+	}
+}
+
+func TestSOCKS5Proxy(t *testing.T) {
+	log := &proxyLog{}
+	proxy := socks5Proxy(t, log)
+	target := helloServer(t, false)
+
+	var body string
+	c := NewClient(target.URL, WithProxy("socks5://user:secret@"+proxy.Addr().String()))
+	if err := c.New("/").Get().Do(&body); err != nil || body != "hello" {
+		t.Fatalf("got %q, %v", body, err)
+	}
+	if want := "CONNECT " + strings.TrimPrefix(target.URL, "http://"); log.String() != want {
+		t.Fatalf("proxy saw %q, want %q", log, want)
+	}
+
+	c = NewClient(target.URL, WithProxy("socks5://user:wrong@"+proxy.Addr().String()))
+	if err := c.New("/").Get().Do(nil); err == nil {
+		t.Fatal("expected the proxy to refuse bad credentials")
+	}
+}
+
+func TestInvalidProxy(t *testing.T) {
+	for _, proxyURL := range []string{"ftp://proxy:21", "http://", "http://a b"} {
+		err := NewClient("http://127.0.0.1:1", WithProxy(proxyURL)).New("/").Get().Do(nil)
+		if err == nil || !strings.Contains(err.Error(), "invalid proxy url") {
+			t.Errorf("WithProxy(%q): got %v", proxyURL, err)
+		}
+	}
+}
+
+func TestProxyKeepsTransport(t *testing.T) {
+	shared := &http.Transport{}
+	c := NewClient("", WithProxy("http://proxy:3128"), WithTransport(shared), WithDialContext((&net.Dialer{}).DialContext))
+	if shared.Proxy != nil || shared.DialContext != nil {
+		t.Fatal("the transport passed to WithTransport was changed")
+	}
+	if c.transport.Proxy == nil || c.transport.DialContext == nil {
+		t.Fatal("options before WithTransport were dropped")
+	}
+}
+
+func TestDialContext(t *testing.T) {
+	target := helloServer(t, false)
+
+	var dialed []string
+	tunnel := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		return (&net.Dialer{}).DialContext(ctx, network, target.Listener.Addr().String())
+	}
+
+	var body string
+	err := NewClient("http://api.internal", WithDialContext(tunnel), WithProxy("")).New("/").Get().Do(&body)
+	if err != nil || body != "hello" {
+		t.Fatalf("got %q, %v", body, err)
+	}
+	if len(dialed) != 1 || dialed[0] != "api.internal:80" {
+		t.Fatalf("dialed %q", dialed)
+	}
+}
@@ -2,60 +2,86 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 )
 
-type Response struct {
-	Code int
-	Body []byte
+// Method sets the HTTP method of the request, and its body unless body is
+// nil, so a body set by Encode is kept by Post(nil).
+func (r *request) Method(method string, body []byte) *request {
+	r.method = method
+	if body != nil {
+		r.body = body
+		r.bodySource = nil
+	}
+	return r
 }
 
-func (s Response) String() string {
-	resp, err := json.Marshal(s)
+// Encode sets the body to v encoded with the codec registered for
+// contentType, and sets the Content-Type header.
+func (r *request) Encode(contentType string, v interface{}) *request {
+	codec, ok := CodecFor(contentType)
+	if !ok {
+		r.setErr(fmt.Errorf("no codec registered for content type %q", contentType))
+		return r
+	}
+	body, err := codec.Marshal(v)
 	if err != nil {
-		return ""
+		r.setErr(err)
+		return r
 	}
+	r.body = body
+	r.bodySource = nil
+	r.headers.Set("Content-Type", contentType)
+	return r
+}
 
-	return string(resp)
+func (r *request) JSON(v interface{}) *request {
+	return r.Encode(JSONCodec{}.ContentType(), v)
 }
 
-func (s Response) Error() string {
-	return s.String()
+func (r *request) XML(v interface{}) *request {
+	return r.Encode(XMLCodec{}.ContentType(), v)
 }
 
-func (c *request) Post(body []byte) *request {
-	buffer := bytes.NewBuffer(body)
-	url := fmt.Sprintf("%s?%s", c.url, c.urlValues.Encode())
-	req, _ := http.NewRequest("POST", url, buffer)
+func (r *request) YAML(v interface{}) *request {
+	return r.Encode(YAMLCodec{}.ContentType(), v)
+}
 
-	req.Header = c.headers.Clone()
-	return &request{request: req}
+func (r *request) Protobuf(v interface{}) *request {
+	return r.Encode(ProtobufCodec{}.ContentType(), v)
 }
 
-func (c *request) Get() *request {
-	url := fmt.Sprintf("%s?%s", c.url, c.urlValues.Encode())
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header = c.headers.Clone()
-	return &request{request: req}
+func (r *request) setErr(err error) {
+	if r.err == nil {
+		r.err = err
+	}
 }
 
-func (c *request) Delete() *request {
-	url := fmt.Sprintf("%s?%s", c.url, c.urlValues.Encode())
-	req, _ := http.NewRequest("DELETE", url, nil)
-	req.Header = c.headers.Clone()
-	return &request{request: req}
+func (r *request) Post(body []byte) *request {
+	return r.Method(http.MethodPost, body)
 }
 
-func (c *request) Put(body []byte) *request {
-	buffer := bytes.NewBuffer(body)
-	url := fmt.Sprintf("%s?%s", c.url, c.urlValues.Encode())
-	req, _ := http.NewRequest("PUT", url, buffer)
-	req.Header = c.headers.Clone()
-	return &request{request: req}
+func (r *request) Get() *request {
+	return r.Method(http.MethodGet, nil)
+}
+
+func (r *request) Delete() *request {
+	return r.Method(http.MethodDelete, nil)
+}
+
+func (r *request) Put(body []byte) *request {
+	return r.Method(http.MethodPut, body)
+}
+
+func (r *request) Patch(body []byte) *request {
+	return r.Method(http.MethodPatch, body)
 }
 
 func (r *request) TLS(tls *tls.Config) *request {
@@ -63,36 +89,174 @@ func (r *request) TLS(tls *tls.Config) *request {
 	return r
 }
 
-func (r *request) SetTimeout(sec time.Duration) *request {
-	r.timeout = sec * time.Second
+// SetTimeout limits the whole request, from dialing to reading the body,
+// overriding the timeout of the client.
+func (r *request) SetTimeout(timeout time.Duration) *request {
+	r.timeout = timeout
 	return r
 }
 
-func (r *request) Do() ([]byte, error) {
-	c := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: r.tls,
-		},
-		Timeout: r.timeout,
+// WithContext sets the context of the request. Cancelling it aborts the
+// request, including reading the response body.
+func (r *request) WithContext(ctx context.Context) *request {
+	if ctx == nil {
+		r.setErr(errors.New("nil context"))
+		return r
 	}
-	response, err := c.Do(r.request)
-	if err != nil {
+	r.ctx = ctx
+	return r
+}
+
+// context returns the context of the request, limited by its timeout. The
+// returned cancel function must be called once the body has been read.
+func (r *request) context() (context.Context, context.CancelFunc) {
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := r.timeout
+	if timeout == 0 {
+		timeout = r.client.timeout
+	}
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// build creates the http.Request. Path params are expanded, and queries added
+// to the builder are merged with the query string of the request url.
+func (r *request) build(ctx context.Context) (*http.Request, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	u := *r.url
+	if err := expandPath(&u, r.pathParams); err != nil {
 		return nil, err
 	}
-	defer func() {
-		_ = response.Body.Close()
-	}()
+	query := u.Query()
+	for key, values := range r.query {
+		query[key] = append(query[key], values...)
+	}
+	u.RawQuery = query.Encode()
 
-	var buf bytes.Buffer
-	_, err = buf.ReadFrom(response.Body)
+	req, err := http.NewRequestWithContext(ctx, r.method, u.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	if response.StatusCode >= 400 {
-		return nil, Response{
-			Code: response.StatusCode,
-			Body: buf.Bytes(),
+	if err := r.setRequestBody(req); err != nil {
+		return nil, err
+	}
+
+	req.Header = r.client.headers.Clone()
+	for key, values := range r.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	return req, nil
+}
+
+// setRequestBody opens the body of req. Rewindable bodies get a GetBody, so
+// they can be retried and signed.
+func (r *request) setRequestBody(req *http.Request) error {
+	source := r.bodySource
+	if source == nil {
+		if r.body == nil {
+			return nil
+		}
+		body := r.body
+		size := int64(len(body))
+		source = &bodySource{
+			rewindable: true,
+			size:       constSize(size),
+			open: func() (io.ReadCloser, int64, error) {
+				return ioutil.NopCloser(bytes.NewReader(body)), size, nil
+			},
+		}
+	}
+
+	open := func() (io.ReadCloser, int64, error) {
+		rc, size, err := source.open()
+		if err != nil {
+			return nil, 0, err
+		}
+		if r.uploadProgress != nil {
+			rc = &progressReader{ReadCloser: rc, total: size, fn: r.uploadProgress}
 		}
+		return rc, size, nil
+	}
+
+	if source.size == nil {
+		// the size is only known once the body is opened, so every attempt
+		// opens it before it is sent
+		rc, size, err := open()
+		if err != nil {
+			return err
+		}
+		req.ContentLength = size
+		if size == 0 {
+			_ = rc.Close()
+			req.Body = http.NoBody
+			return nil
+		}
+		req.Body = rc
+		if source.rewindable {
+			req.GetBody = func() (io.ReadCloser, error) {
+				rc, _, err := open()
+				return rc, err
+			}
+		}
+		return nil
+	}
+
+	size, err := source.size()
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+		return nil
 	}
-	return buf.Bytes(), nil
+
+	lazy := func() (io.ReadCloser, error) {
+		return &lazyBody{open: func() (io.ReadCloser, error) {
+			rc, _, err := open()
+			return rc, err
+		}}, nil
+	}
+	req.Body, _ = lazy()
+	if source.rewindable {
+		req.GetBody = lazy
+	}
+	return nil
+}
+
+// open builds and sends the request. The caller must close the body of the
+// response.
+func (r *request) open(ctx context.Context) (*http.Response, error) {
+	req, err := r.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.send(r.client.httpClient(r.tls), req)
+}
+
+// Do sends the request, runs the pre-handlers on the response body, decodes it
+// into out using the codec of its Content-Type and runs the post-handlers.
+// out may be nil to discard the body, or a *[]byte or *string to receive it
+// unchanged. Responses with a status of 400 or more are returned as an
+// *HTTPError without running the handlers. Use Send to inspect the status
+// and headers of successful responses too.
+func (r *request) Do(out interface{}) error {
+	response, err := r.Send()
+	if err != nil {
+		return err
+	}
+	return response.Decode(out)
+}
+
+// Into is Do, reading better at the end of a chain: Get().Into(&user).
+func (r *request) Into(out interface{}) error {
+	return r.Do(out)
 }
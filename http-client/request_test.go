@@ -0,0 +1,80 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func echoQueryServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSettingsSurviveVerbs(t *testing.T) {
+	cfg := &tls.Config{}
+	r := New("http://example.com").TLS(cfg).SetTimeout(5 * time.Second).Post([]byte("{}"))
+	if r.tls != cfg || r.timeout != 5*time.Second || r.method != http.MethodPost {
+		t.Fatalf("settings lost: %+v", r)
+	}
+}
+
+func TestQueryMerge(t *testing.T) {
+	server := echoQueryServer(t)
+
+	var body string
+	err := New(server.URL+"/list?page=1&sort=name").
+		AddQuery("page", "2").
+		AddQuery("desc", "true").
+		Get().
+		Do(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := body, "desc=true&page=1&page=2&sort=name"; got != want {
+		t.Fatalf("got query %q, want %q", got, want)
+	}
+}
+
+func TestConstructionErrors(t *testing.T) {
+	if err := New("192.168.0.1:8080/api").Get().Do(nil); err == nil {
+		t.Fatal("expected an error for a url without scheme")
+	}
+	if err := New("http://example.com").Method("BAD METHOD", nil).Do(nil); err == nil {
+		t.Fatal("expected an error for an invalid method")
+	}
+}
+
+func TestCloneConcurrent(t *testing.T) {
+	server := echoQueryServer(t)
+	base := New(server.URL).AddQuery("shared", "1")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var body string
+			err := base.Clone().AddQuery("n", string(rune('a'+i))).Get().Do(&body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want := "n=" + string(rune('a'+i)) + "&shared=1"; body != want {
+				t.Errorf("got %q, want %q", body, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(base.query) != 1 {
+		t.Fatalf("clones changed the original: %v", base.query)
+	}
+}
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Response is a response whose body was read.
+type Response struct {
+	StatusCode int
+	// Status is the status line text, e.g. "404 Not Found".
+	Status string
+	Header http.Header
+	Body   []byte
+	// Request is the last request sent, after retries and redirects.
+	Request *http.Request
+	// Duration is the time from sending the first attempt to reading the
+	// whole body.
+	Duration time.Duration
+
+	request *request
+}
+
+// IsSuccess reports whether the status is 2xx.
+func (s *Response) IsSuccess() bool {
+	return s.StatusCode >= 200 && s.StatusCode < 300
+}
+
+// IsError reports whether the status is 400 or more.
+func (s *Response) IsError() bool {
+	return s.StatusCode >= 400
+}
+
+// ContentType returns the Content-Type header.
+func (s *Response) ContentType() string {
+	return s.Header.Get("Content-Type")
+}
+
+// String returns the body.
+func (s *Response) String() string {
+	return string(s.Body)
+}
+
+// Decode runs the handlers of the request on the body and decodes it into out,
+// as Do does.
+func (s *Response) Decode(out interface{}) error {
+	return s.request.handle(s.ContentType(), s.Body, out)
+}
+
+// Send sends the request and reads the response. A status of 400 or more
+// returns the response together with an *HTTPError.
+func (r *request) Send() (*Response, error) {
+	ctx, cancel := r.context()
+	defer cancel()
+
+	start := time.Now()
+	resp, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	response, err := r.readResponse(resp, start, -1)
+	if err != nil {
+		return nil, err
+	}
+	if response.IsError() {
+		return response, r.httpError(response)
+	}
+	return response, nil
+}
+
+// readResponse reads up to limit bytes of the body, all of it when limit is
+// negative.
+func (r *request) readResponse(resp *http.Response, start time.Time, limit int64) (*Response, error) {
+	var body bytes.Buffer
+	reader := io.Reader(resp.Body)
+	if limit >= 0 {
+		reader = io.LimitReader(resp.Body, limit)
+	}
+	if _, err := io.Copy(&body, reader); err != nil {
+		return nil, err
+	}
+
+	status := resp.Status
+	if status == "" {
+		status = strconv.Itoa(resp.StatusCode) + " " + http.StatusText(resp.StatusCode)
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Status:     status,
+		Header:     resp.Header,
+		Body:       body.Bytes(),
+		Request:    resp.Request,
+		Duration:   time.Since(start),
+		request:    r,
+	}, nil
+}
+
+// WithErrorType sets the type error bodies are decoded into, using the codec
+// of their Content-Type, for every request of the client. v is an example
+// value such as ProblemDetails{}; the decoded value is a pointer to a new one.
+func WithErrorType(v interface{}) ClientSettingFunc {
+	return func(c *Client) {
+		c.errorType = errorType(v)
+	}
+}
+
+// ErrorType sets the type error bodies are decoded into, like WithErrorType.
+func (r *request) ErrorType(v interface{}) *request {
+	r.errorType = errorType(v)
+	return r
+}
+
+func errorType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// HTTPError is returned for responses with a status of 400 or more.
+//
+// errors.Is matches it against the status classes ErrClientError and
+// ErrServerError and the statuses ErrUnauthorized, ErrForbidden, ErrNotFound,
+// ErrConflict and ErrTooManyRequests. When the decoded Payload is an error,
+// errors.As also finds it.
+type HTTPError struct {
+	Method string
+	// URL is the request url with its password redacted.
+	URL        string
+	StatusCode int
+	Status     string
+	Body       []byte
+	// Payload is the body decoded into the error type of the request or the
+	// client, nil if there is none or the body did not decode.
+	Payload  interface{}
+	Response *Response
+}
+
+func (r *request) httpError(resp *Response) *HTTPError {
+	e := &HTTPError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       resp.Body,
+		Response:   resp,
+	}
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		e.URL = resp.Request.URL.Redacted()
+	} else if r.url != nil {
+		e.Method = r.method
+		e.URL = r.url.Redacted()
+	}
+
+	t := r.errorType
+	if t == nil {
+		t = r.client.errorType
+	}
+	if t != nil && len(resp.Body) > 0 {
+		payload := reflect.New(t).Interface()
+		contentType := resp.ContentType()
+		if contentType == "" {
+			contentType = JSONCodec{}.ContentType()
+		}
+		if decode(contentType, resp.Body, payload) == nil {
+			e.Payload = payload
+		}
+	}
+	return e
+}
+
+func (e *HTTPError) Error() string {
+	msg := e.Method + " " + e.URL + ": " + e.Status
+	if excerpt := bodyExcerpt(e.Body, 256); excerpt != "" {
+		msg += ": " + excerpt
+	}
+	return msg
+}
+
+// Unwrap returns the payload when it is an error.
+func (e *HTTPError) Unwrap() error {
+	err, _ := e.Payload.(error)
+	return err
+}
+
+// Is matches the status classes and statuses declared by this package.
+func (e *HTTPError) Is(target error) bool {
+	class, ok := target.(*statusClass)
+	return ok && class.min <= e.StatusCode && e.StatusCode <= class.max
+}
+
+// bodyExcerpt returns the body on a single line, cut to max bytes, or its
+// size when it is not text.
+func bodyExcerpt(body []byte, max int) string {
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("(%d bytes of binary body)", len(body))
+	}
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if strings.IndexFunc(text, func(c rune) bool { return !unicode.IsPrint(c) }) >= 0 {
+		return fmt.Sprintf("(%d bytes of binary body)", len(body))
+	}
+	if len(text) > max {
+		cut := max
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut] + "..."
+	}
+	return text
+}
+
+type statusClass struct {
+	min, max int
+	name     string
+}
+
+func (c *statusClass) Error() string {
+	return c.name
+}
+
+var (
+	ErrClientError     error = &statusClass{400, 499, "client error"}
+	ErrServerError     error = &statusClass{500, 599, "server error"}
+	ErrUnauthorized    error = &statusClass{401, 401, "unauthorized"}
+	ErrForbidden       error = &statusClass{403, 403, "forbidden"}
+	ErrNotFound        error = &statusClass{404, 404, "not found"}
+	ErrConflict        error = &statusClass{409, 409, "conflict"}
+	ErrTooManyRequests error = &statusClass{429, 429, "too many requests"}
+)
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ProblemDetails is an RFC 7807 error body.
+type ProblemDetails struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func (p *ProblemDetails) Error() string {
+	return p.Title + ": " + p.Detail
+}
+
+func statusServer(t *testing.T, code int, contentType, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("ETag", `"v1"`)
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSend(t *testing.T) {
+	server := statusServer(t, http.StatusCreated, "application/json", `{"name":"tom","age":3}`)
+
+	resp, err := New(server.URL + "/users").JSON(User{Name: "tom"}).Post(nil).Send()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Status != "201 Created" || !resp.IsSuccess() || resp.IsError() {
+		t.Fatalf("got status %d %q", resp.StatusCode, resp.Status)
+	}
+	if resp.Header.Get("ETag") != `"v1"` || resp.ContentType() != "application/json" {
+		t.Fatalf("got headers %v", resp.Header)
+	}
+	if resp.Request.Method != http.MethodPost || resp.Request.URL.Path != "/users" || resp.Duration <= 0 {
+		t.Fatalf("got request %s %s in %s", resp.Request.Method, resp.Request.URL, resp.Duration)
+	}
+
+	user := User{}
+	if err := resp.Decode(&user); err != nil || user.Age != 3 || resp.String() != `{"name":"tom","age":3}` {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	server := statusServer(t, http.StatusNotFound, "application/problem+json",
+		`{"title": "not found",
+		  "detail": "user 42 does not exist"}`)
+	u := strings.Replace(server.URL, "http://", "http://admin:secret@", 1) + "/users/42"
+
+	resp, err := New(u).ErrorType(ProblemDetails{}).Get().Send()
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("got %v, want an *HTTPError", err)
+	}
+	if resp == nil || httpErr.Response != resp || httpErr.StatusCode != http.StatusNotFound {
+		t.Fatal("the response must be returned with the error")
+	}
+
+	want := "GET http://admin:xxxxx@" + strings.TrimPrefix(server.URL, "http://") +
+		`/users/42: 404 Not Found: {"title": "not found", "detail": "user 42 does not exist"}`
+	if err.Error() != want {
+		t.Fatalf("got  %s\nwant %s", err, want)
+	}
+
+	if !errors.Is(err, ErrNotFound) || !errors.Is(err, ErrClientError) || errors.Is(err, ErrServerError) {
+		t.Fatal("the error must match 404 and 4xx only")
+	}
+	var problem *ProblemDetails
+	if !errors.As(err, &problem) || problem.Detail != "user 42 does not exist" {
+		t.Fatalf("got payload %#v", httpErr.Payload)
+	}
+}
+
+func TestClientErrorType(t *testing.T) {
+	server := statusServer(t, http.StatusServiceUnavailable, "", `{"title":"maintenance"}`)
+
+	err := NewClient(server.URL, WithErrorType(&ProblemDetails{})).New("/").Get().Do(nil)
+	var problem *ProblemDetails
+	if !errors.Is(err, ErrServerError) || !errors.As(err, &problem) || problem.Title != "maintenance" {
+		t.Fatalf("got %v", err)
+	}
+
+	err = New(server.URL).Get().Do(nil)
+	if httpErr := err.(*HTTPError); httpErr.Payload != nil {
+		t.Fatalf("got payload %v without an error type", httpErr.Payload)
+	}
+}
+
+func TestBodyExcerpt(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"", ""},
+		{"not\n\tfound  ", "not found"},
+		{strings.Repeat("é", 10), strings.Repeat("é", 4) + "..."},
+		{"\x89PNG\r\n\x1a\n\x00", "(9 bytes of binary body)"},
+		{"\xff\xfe", "(2 bytes of binary body)"},
+	}
+	for _, tt := range tests {
+		if got := bodyExcerpt([]byte(tt.body), 9); got != tt.want {
+			t.Errorf("bodyExcerpt(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryCondition reports whether an attempt that returned resp or err should
+// be retried. resp is nil when err is not.
+type RetryCondition func(resp *http.Response, err error) bool
+
+// RetryPolicy retries failed attempts with exponential backoff and full
+// jitter: before attempt n the request waits a random duration between zero
+// and min(MaxDelay, BaseDelay*2^n), or as long as the Retry-After header of the
+// response asks, up to MaxDelay.
+//
+// Requests with a non-idempotent method (POST, PATCH) are only retried when
+// they carry an Idempotency-Key header. No attempt is started that could not
+// finish before the deadline of the request context.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; values below 2 disable retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RetryOn lists the conditions to retry on, any of them matching is
+	// enough. DefaultRetryOn is used when it is empty.
+	RetryOn []RetryCondition
+}
+
+// DefaultRetryPolicy retries transient network errors, 429, 502, 503 and 504
+// up to three times.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+var DefaultRetryOn = []RetryCondition{
+	RetryOnNetworkError,
+	RetryOnStatus(http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout),
+}
+
+// RetryOnStatus retries responses with one of the given status codes.
+func RetryOnStatus(codes ...int) RetryCondition {
+	return func(resp *http.Response, err error) bool {
+		if resp == nil {
+			return false
+		}
+		for _, code := range codes {
+			if resp.StatusCode == code {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RetryOnNetworkError retries errors of the transport, such as refused or
+// reset connections, but not the cancellation of the request context.
+func RetryOnNetworkError(resp *http.Response, err error) bool {
+	return err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// WithRetry sets the retry policy of every request of the client.
+func WithRetry(policy RetryPolicy) ClientSettingFunc {
+	return func(c *Client) {
+		c.retry = &policy
+	}
+}
+
+// Retry sets the retry policy of the request, overriding the client's.
+func (r *request) Retry(policy RetryPolicy) *request {
+	r.retry = &policy
+	return r
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func (p RetryPolicy) shouldRetry(resp *http.Response, err error) bool {
+	conditions := p.RetryOn
+	if len(conditions) == 0 {
+		conditions = DefaultRetryOn
+	}
+	for _, retryOn := range conditions {
+		if retryOn(resp, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the given retry, counted from 0.
+func (p RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		if p.MaxDelay > 0 && d > p.MaxDelay {
+			d = p.MaxDelay
+		}
+		return d
+	}
+
+	ceiling := p.MaxDelay
+	if retry < 62 && p.BaseDelay > 0 {
+		if d := p.BaseDelay << uint(retry); d > 0 && (ceiling <= 0 || d < ceiling) {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// retryAfter parses the Retry-After header, in seconds or as an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// send performs req with the retry policy of the request. The body is rewound
+// with GetBody before every retry; requests whose body cannot be rewound are
+// sent once.
+func (r *request) send(client *http.Client, req *http.Request) (*http.Response, error) {
+	roundTrip := r.roundTrip(client)
+	policy := r.retry
+	if policy == nil {
+		policy = r.client.retry
+	}
+	if policy == nil || policy.MaxAttempts < 2 || !isIdempotent(req) ||
+		(req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return roundTrip(req)
+	}
+
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := roundTrip(req)
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt-1, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			// the next attempt could not finish in time
+			return resp, err
+		}
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		next := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			next.Body = body
+		}
+		req = next
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer fails the first failures requests with 503 and records the
+// bodies it received.
+func flakyServer(t *testing.T, failures int32, header http.Header) (*httptest.Server, *int32, *[]string) {
+	var calls int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls, &bodies
+}
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+func TestRetry(t *testing.T) {
+	server, calls, _ := flakyServer(t, 2, nil)
+
+	var body string
+	if err := NewClient(server.URL, WithRetry(fastRetry)).New("/").Get().Do(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body != "ok" || *calls != 3 {
+		t.Fatalf("got %q after %d calls", body, *calls)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	server, calls, _ := flakyServer(t, 5, nil)
+
+	err := New(server.URL).Retry(fastRetry).Get().Do(nil)
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("got %v, want the last 503", err)
+	}
+	if *calls != 3 {
+		t.Fatalf("got %d calls, want 3", *calls)
+	}
+}
+
+func TestRetryIdempotency(t *testing.T) {
+	server, calls, bodies := flakyServer(t, 1, nil)
+
+	if err := New(server.URL).Retry(fastRetry).Post([]byte("a")).Do(nil); err == nil {
+		t.Fatal("a POST without Idempotency-Key must not be retried")
+	}
+	if *calls != 1 {
+		t.Fatalf("got %d calls, want 1", *calls)
+	}
+
+	err := New(server.URL).Retry(fastRetry).AddHeader("Idempotency-Key", "k1").Post([]byte("b")).Do(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := *bodies; len(got) != 2 || got[1] != "b" {
+		t.Fatalf("body was not rewound: %q", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	server, calls, _ := flakyServer(t, 1, http.Header{"Retry-After": {"1"}})
+
+	patient := RetryPolicy{MaxAttempts: 3, MaxDelay: time.Minute}
+	start := time.Now()
+	if err := New(server.URL).Retry(patient).Get().Do(nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || *calls != 2 {
+		t.Fatalf("retried after %s, %d calls", elapsed, *calls)
+	}
+}
+
+func TestRetryAfterCapped(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3600"}}}
+	if d := fastRetry.backoff(0, resp); d != fastRetry.MaxDelay {
+		t.Fatalf("got %s, want Retry-After capped to MaxDelay %s", d, fastRetry.MaxDelay)
+	}
+	if d := (RetryPolicy{}).backoff(0, resp); d != time.Hour {
+		t.Fatalf("got %s, want Retry-After without MaxDelay", d)
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	server, calls, _ := flakyServer(t, 5, http.Header{"Retry-After": {"10"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	patient := RetryPolicy{MaxAttempts: 3, MaxDelay: time.Minute}
+	err := New(server.URL).WithContext(ctx).Retry(patient).Get().Do(nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond || *calls != 1 {
+		t.Fatalf("waited %s and made %d calls past the deadline", elapsed, *calls)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	for retry := 0; retry < 10; retry++ {
+		ceiling := 10 * time.Millisecond << uint(retry)
+		if ceiling > 50*time.Millisecond {
+			ceiling = 50 * time.Millisecond
+		}
+		if d := p.backoff(retry, nil); d < 0 || d > ceiling {
+			t.Fatalf("retry %d: delay %s outside [0, %s]", retry, d, ceiling)
+		}
+	}
+}
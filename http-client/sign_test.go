@@ -0,0 +1,135 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var signingTime = func() time.Time {
+	return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+}
+
+// The vectors come from the AWS Signature Version 4 test suite and the IAM
+// example of the SigV4 documentation.
+func TestSigV4Vectors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		signer  SigV4Signer
+		want    string
+	}{
+		{
+			name: "get-vanilla",
+			request: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+				return req
+			},
+			signer: SigV4Signer{Region: "us-east-1", Service: "service"},
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, " +
+				"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "iam ListUsers",
+			request: func() *http.Request {
+				req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+				return req
+			},
+			signer: SigV4Signer{Region: "us-east-1", Service: "iam"},
+			want: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := tt.signer
+			signer.AccessKey = "AKIDEXAMPLE"
+			signer.SecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+			signer.Now = signingTime
+
+			req := tt.request()
+			if err := signer.Authenticate(req); err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Fatalf("got X-Amz-Date %s", got)
+			}
+		})
+	}
+}
+
+func TestCanonicalRequest(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/a/./b/../c%20d/?b=2&a=2&a=1&c=x+y", nil)
+	if got, want := canonicalPath(req.URL, true), "/a/c%20d/"; got != want {
+		t.Fatalf("got path %q, want %q", got, want)
+	}
+	if got, want := canonicalPath(req.URL, false), "/a/./b/../c%20d/"; got != want {
+		t.Fatalf("got s3 path %q, want %q", got, want)
+	}
+	if got, want := canonicalQuery(req.URL), "a=1&a=2&b=2&c=x%20y"; got != want {
+		t.Fatalf("got query %q, want %q", got, want)
+	}
+
+	req.Header.Set("X-Amz-Meta", "  a   b ")
+	req.Header.Add("X-Amz-Meta", "c")
+	headers, signed := canonicalHeaders(req, func(string) bool { return true })
+	if want := "host:example.com\nx-amz-meta:a b,c\n"; headers != want {
+		t.Fatalf("got headers %q, want %q", headers, want)
+	}
+	if signed != "host;x-amz-meta" {
+		t.Fatalf("got signed headers %q", signed)
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	var authorization, date string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, date = r.Header.Get("Authorization"), r.Header.Get("X-Date")
+	}))
+	defer server.Close()
+
+	signer := HMACSigner{AccessKey: "ak", SecretKey: "sk", SignedHeaders: []string{"Content-Type"}, Now: signingTime}
+	err := NewClient(server.URL, WithAuth(signer)).New("/v1/hosts").AddQuery("zone", "a").JSON(map[string]int{"n": 1}).Post(nil).Do(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	stringToSign := "POST\n/v1/hosts\nzone=a\n" +
+		"content-type:application/json\nhost:" + host + "\nx-date:20150830T123600Z\n\n" +
+		"content-type;host;x-date\n" + hexSHA256([]byte(`{"n":1}`)) + "\n20150830T123600Z"
+	mac := hmac.New(sha256.New, []byte("sk"))
+	mac.Write([]byte(stringToSign))
+
+	want := "HMAC-SHA256 Credential=ak, SignedHeaders=content-type;host;x-date, Signature=" + hex.EncodeToString(mac.Sum(nil))
+	if authorization != want || date != "20150830T123600Z" {
+		t.Fatalf("got  %s\nwant %s", authorization, want)
+	}
+}
+
+func TestSignStreamingBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/key", strings.NewReader("data"))
+	req.GetBody = nil
+	if err := (SigV4Signer{Service: "s3"}).Authenticate(req); err == nil {
+		t.Fatal("expected an error for a body that cannot be rewound")
+	}
+
+	if err := (SigV4Signer{Service: "s3", UnsignedPayload: true}).Authenticate(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != unsignedPayload {
+		t.Fatalf("got payload hash %q", got)
+	}
+}
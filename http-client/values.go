@@ -0,0 +1,252 @@
+package client
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// PathParam sets the value of the {name} placeholder of the request path. The
+// value is escaped, so it may contain slashes or spaces.
+//
+//	c.New("/clusters/{cluster}/nodes/{node}").
+//		PathParam("cluster", "prod").
+//		PathParam("node", "n1")
+func (r *request) PathParam(name, value string) *request {
+	if r.pathParams == nil {
+		r.pathParams = map[string]string{}
+	}
+	r.pathParams[name] = value
+	return r
+}
+
+// PathParams sets the values of several placeholders of the request path.
+func (r *request) PathParams(params map[string]string) *request {
+	for name, value := range params {
+		r.PathParam(name, value)
+	}
+	return r
+}
+
+// AddQueryStruct adds the fields of the struct v to the query, as encoded by
+// EncodeValues.
+func (r *request) AddQueryStruct(v interface{}) *request {
+	values, err := EncodeValues(v)
+	if err != nil {
+		r.setErr(fmt.Errorf("encode query: %w", err))
+		return r
+	}
+	for key, vs := range values {
+		r.query[key] = append(r.query[key], vs...)
+	}
+	return r
+}
+
+// expandPath replaces the {name} placeholders of the path of u with the
+// escaped path params.
+func expandPath(u *url.URL, params map[string]string) error {
+	if !strings.Contains(u.Path, "{") {
+		return nil
+	}
+
+	var escaped strings.Builder
+	p := u.Path
+	for {
+		open := strings.IndexByte(p, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(p[open:], '}')
+		if end < 0 {
+			return fmt.Errorf("unterminated path parameter in %q", u.Path)
+		}
+		name := p[open+1 : open+end]
+		value, ok := params[name]
+		if !ok {
+			return fmt.Errorf("missing path parameter %q of %q", name, u.Path)
+		}
+		escaped.WriteString((&url.URL{Path: p[:open]}).EscapedPath())
+		escaped.WriteString(url.PathEscape(value))
+		p = p[open+end+1:]
+	}
+	escaped.WriteString((&url.URL{Path: p}).EscapedPath())
+
+	path, err := url.PathUnescape(escaped.String())
+	if err != nil {
+		return err
+	}
+	u.Path, u.RawPath = path, escaped.String()
+	return nil
+}
+
+// EncodeValues encodes the exported fields of the struct v, or of the struct
+// v points to, as url values. The name of a field is given by its url tag, or
+// its form tag, or else the field name; "-" skips the field:
+//
+//	type ListOptions struct {
+//		Labels []string  `url:"label"`           // label=a&label=b
+//		Limit  *int      `url:"limit,omitempty"` // skipped when nil
+//		Since  time.Time `url:"since,omitempty"` // RFC 3339
+//		Until  time.Time `url:"until,unix"`      // unix seconds
+//		Day    time.Time `url:"day" layout:"2006-01-02"`
+//		Secret string    `url:"-"`
+//	}
+//
+// Slices and arrays repeat the name for every element, nil pointers are
+// skipped and omitempty skips zero values, but not pointers to them. Times
+// are formatted with the layout tag, RFC 3339 by default, and
+// encoding.TextMarshalers with MarshalText. Fields of embedded structs are
+// encoded as fields of the outer struct.
+func EncodeValues(v interface{}) (url.Values, error) {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot encode %T as url values, a struct is required", v)
+	}
+	if err := encodeStruct(values, rv); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func encodeStruct(values url.Values, rv reflect.Value) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("url")
+		if !ok {
+			tag = field.Tag.Get("form")
+		}
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		omitempty := containsString(strings.Split(opts, ","), "omitempty")
+		unix := containsString(strings.Split(opts, ","), "unix")
+
+		fv := rv.Field(i)
+		pointer := fv.Kind() == reflect.Ptr
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr {
+			continue
+		}
+		// a set pointer is sent even when it points to a zero value
+		omitempty = omitempty && !pointer
+
+		if field.Anonymous && name == "" && fv.Kind() == reflect.Struct && !isScalar(fv.Type()) {
+			if err := encodeStruct(values, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if (fv.Kind() == reflect.Slice || fv.Kind() == reflect.Array) && fv.Type().Elem().Kind() != reflect.Uint8 {
+			if omitempty && fv.Len() == 0 {
+				continue
+			}
+			for j := 0; j < fv.Len(); j++ {
+				s, err := encodeValue(fv.Index(j), field.Tag.Get("layout"), unix)
+				if err != nil {
+					return fmt.Errorf("field %s: %w", field.Name, err)
+				}
+				values.Add(name, s)
+			}
+			continue
+		}
+
+		if omitempty && fv.IsZero() {
+			continue
+		}
+		s, err := encodeValue(fv, field.Tag.Get("layout"), unix)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		values.Add(name, s)
+	}
+	return nil
+}
+
+// isScalar reports whether values of t are encoded as a single value.
+func isScalar(t reflect.Type) bool {
+	return t == timeType || t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)
+}
+
+func encodeValue(v reflect.Value, layout string, unix bool) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if unix {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return t.Format(layout), nil
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), err
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return string(b), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
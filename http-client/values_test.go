@@ -0,0 +1,111 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Page struct {
+	Page     int `url:"page,omitempty"`
+	PageSize int `url:"pageSize,omitempty"`
+}
+
+type ListNodes struct {
+	Page
+	Labels  []string  `url:"label"`
+	Ready   *bool     `url:"ready,omitempty"`
+	Zone    *string   `url:"zone"`
+	Since   time.Time `url:"since,omitempty"`
+	Until   time.Time `url:"until,unix"`
+	Day     time.Time `url:"day" layout:"2006-01-02"`
+	IP      net.IP    `form:"ip"`
+	Ratio   float64   `url:"ratio"`
+	Ports   [2]uint16 `url:"port"`
+	Sort    string    `url:"sort,omitempty"`
+	Search  string
+	Skipped string `url:"-"`
+	hidden  string
+}
+
+func TestEncodeValues(t *testing.T) {
+	ready := false
+	day := time.Date(2021, 6, 1, 8, 30, 0, 0, time.UTC)
+	values, err := EncodeValues(&ListNodes{
+		Page:   Page{PageSize: 20},
+		Labels: []string{"role=master", "zone=a"},
+		Ready:  &ready,
+		Until:  day,
+		Day:    day,
+		IP:     net.ParseIP("10.0.0.1"),
+		Ratio:  0.5,
+		Ports:  [2]uint16{80, 443},
+		Search: "a b",
+		hidden: "x",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "Search=a+b&day=2021-06-01&ip=10.0.0.1&label=role%3Dmaster&label=zone%3Da&pageSize=20" +
+		"&port=80&port=443&ratio=0.5&ready=false&until=1622536200"
+	if got := values.Encode(); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+
+	if _, err := EncodeValues(map[string]string{}); err == nil {
+		t.Fatal("expected an error for a map")
+	}
+	if _, err := EncodeValues(struct{ M map[string]int }{}); err == nil {
+		t.Fatal("expected an error for a map field")
+	}
+	if values, err := EncodeValues((*ListNodes)(nil)); err != nil || len(values) != 0 {
+		t.Fatalf("got %v, %v for a nil pointer", values, err)
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	var path, rawQuery string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, rawQuery = r.URL.EscapedPath(), r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL + "/api/v1")
+	err := c.New("/clusters/{cluster}/nodes/{node}").
+		PathParam("cluster", "prod/east").
+		PathParams(map[string]string{"node": "node 1"}).
+		AddQueryStruct(Page{Page: 2}).
+		Get().
+		Do(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/api/v1/clusters/prod%2Feast/nodes/node%201" || rawQuery != "page=2" {
+		t.Fatalf("got %s?%s", path, rawQuery)
+	}
+
+	err = c.New("/clusters/{cluster}").Get().Do(nil)
+	if err == nil || !strings.Contains(err.Error(), `missing path parameter "cluster"`) {
+		t.Fatalf("got %v", err)
+	}
+	if err := c.New("/").AddQueryStruct("page=1").Get().Do(nil); err == nil {
+		t.Fatal("expected the encoding error to be returned by Do")
+	}
+}
+
+func TestFormStruct(t *testing.T) {
+	server := uploadServer(t)
+
+	var body string
+	err := New(server.URL).Encode(FormCodec{}.ContentType(), Page{Page: 1, PageSize: 50}).Post(nil).Do(&body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "18 [] page=1&pageSize=50" {
+		t.Fatalf("got %q", body)
+	}
+}
@@ -55,6 +55,16 @@ type Connection interface {
 	Scp(src, dst string) error
 }
 
+// TunnelDialer opens connections to addr from the remote host, e.g. to reach
+// services behind a bastion. The connections returned by NewConnection
+// implement it:
+//
+//	dialer, ok := conn.(ssh.TunnelDialer)
+type TunnelDialer interface {
+	Dial(network, addr string) (net.Conn, error)
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
 type Cfg struct {
 	Username    string
 	Password    string
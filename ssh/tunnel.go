@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+)
+
+// Dial opens a connection to addr through the ssh connection, like
+// "ssh -W addr". Its DialContext can be used as the dial hook of an HTTP
+// client to reach services only reachable from the remote host:
+//
+//	dialer := conn.(ssh.TunnelDialer)
+//	client.NewClient(base, client.WithDialContext(dialer.DialContext))
+func (c *connection) Dial(network, addr string) (net.Conn, error) {
+	return c.DialContext(context.Background(), network, addr)
+}
+
+// DialContext is Dial, giving up when ctx is done before the remote host
+// connected.
+func (c *connection) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	client := c.sshclient
+	c.mu.Unlock()
+	if client == nil {
+		return nil, errors.New("connection closed")
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := client.Dial(network, addr)
+		done <- result{conn, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, errors.Wrapf(r.err, "could not dial %s through ssh", addr)
+		}
+		return r.conn, nil
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.conn != nil {
+				_ = r.conn.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
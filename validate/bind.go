@@ -0,0 +1,289 @@
+package validate
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultMaxMemory = 32 << 20
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	unmarshalType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindError is a value that could not be converted into its field.
+type BindError struct {
+	Field string
+	Key   string
+	Value string
+	Err   error
+}
+
+func (e BindError) Error() string {
+	return fmt.Sprintf("\"%s\" cannot bind %q from %q: %s", e.Field, e.Value, e.Key, e.Err.Error())
+}
+
+func (e BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindErrors holds every conversion failure of a bind.
+type BindErrors []BindError
+
+func (e BindErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// BindValues decodes values into the struct pointed to by dst using the
+// `form` tag of each field, falling back to the field name, and then runs
+// Validate. Time fields are parsed with the `time_format` tag, RFC 3339 by
+// default. Conversion errors are returned together as BindErrors.
+func BindValues(values url.Values, dst interface{}) error {
+	return bind(values, nil, dst)
+}
+
+// BindRequest binds the query string of GET and HEAD requests, and the query
+// string plus url-encoded or multipart form body of any other request.
+// *multipart.FileHeader fields receive uploaded files.
+func BindRequest(r *http.Request, dst interface{}) error {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return bind(r.URL.Query(), nil, dst)
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(defaultMaxMemory); err != nil {
+			return err
+		}
+		return bind(r.Form, r.MultipartForm.File, dst)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	return bind(r.Form, nil, dst)
+}
+
+func bind(values url.Values, files map[string][]*multipart.FileHeader, dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("bind destination must be a non-nil pointer to a struct")
+	}
+
+	var errs BindErrors
+	bindStruct(v.Elem(), values, files, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return Validate(dst)
+}
+
+// bindStruct sets the fields of v and reports whether any of their keys were
+// present.
+func bindStruct(v reflect.Value, values url.Values, files map[string][]*multipart.FileHeader, errs *BindErrors) bool {
+	bound := false
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		types := t.Field(i)
+		if types.PkgPath != "" {
+			continue
+		}
+
+		key := strings.Split(types.Tag.Get("form"), ",")[0]
+		if key == IgnoreFields {
+			continue
+		}
+		if key == "" {
+			key = types.Name
+		}
+
+		if types.Type == fileHeaderType || types.Type == reflect.SliceOf(fileHeaderType) {
+			if len(files[key]) > 0 {
+				bindFiles(field, files[key])
+				bound = true
+			}
+			continue
+		}
+
+		if isNestedStruct(types.Type) {
+			if types.Type.Kind() != reflect.Ptr {
+				bound = bindStruct(field, values, files, errs) || bound
+				continue
+			}
+			if !field.IsNil() {
+				bound = bindStruct(field.Elem(), values, files, errs) || bound
+				continue
+			}
+			// a nil pointer stays nil unless one of its keys is present
+			nested := reflect.New(types.Type.Elem())
+			if bindStruct(nested.Elem(), values, files, errs) {
+				field.Set(nested)
+				bound = true
+			}
+			continue
+		}
+
+		raw, ok := values[key]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		bound = true
+
+		for _, err := range setField(field, raw, types.Tag.Get("time_format")) {
+			*errs = append(*errs, BindError{Field: types.Name, Key: key, Value: err.value, Err: err.err})
+		}
+	}
+	return bound
+}
+
+// isNestedStruct reports whether t is a struct, or pointer to one, whose own
+// fields should be bound rather than the struct as a single value.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType &&
+		!reflect.PtrTo(t).Implements(unmarshalType)
+}
+
+func bindFiles(field reflect.Value, headers []*multipart.FileHeader) {
+	if len(headers) == 0 {
+		return
+	}
+	if field.Kind() == reflect.Slice {
+		field.Set(reflect.ValueOf(headers))
+		return
+	}
+	field.Set(reflect.ValueOf(headers[0]))
+}
+
+type valueError struct {
+	value string
+	err   error
+}
+
+// setField converts raw into field. Slices take every value, other kinds the
+// first one.
+func setField(field reflect.Value, raw []string, layout string) []valueError {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		var errs []valueError
+		slice := reflect.MakeSlice(field.Type(), len(raw), len(raw))
+		for j, s := range raw {
+			if err := setValue(slice.Index(j), s, layout); err != nil {
+				errs = append(errs, valueError{value: s, err: err})
+			}
+		}
+		field.Set(slice)
+		return errs
+	}
+
+	if err := setValue(field, raw[0], layout); err != nil {
+		return []valueError{{value: raw[0], err: err}}
+	}
+	return nil
+}
+
+// SetString converts s into v with the same rules as binding. Slices other
+// than []byte are filled from a comma separated list.
+func SetString(v reflect.Value, s string) error {
+	raw := []string{s}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		raw = strings.Split(s, ",")
+		for i := range raw {
+			raw[i] = strings.TrimSpace(raw[i])
+		}
+	}
+
+	if errs := setField(v, raw, ""); len(errs) > 0 {
+		return errs[0].err
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, s, layout string) error {
+	if field.Kind() == reflect.Ptr {
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), s, layout); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok && field.Type() != timeType {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch field.Type() {
+	case timeType:
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, s)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(tm))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		if s == "on" {
+			s = "true"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		// []byte takes the raw value
+		field.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
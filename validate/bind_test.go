@@ -0,0 +1,140 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Query struct {
+	Struct
+	Tags    []string      `form:"tag"`
+	IDs     []int         `form:"id"`
+	Limit   *int          `form:"limit"`
+	Size    int           `form:"size" validate:"lte=100"`
+	Since   time.Time     `form:"since" time_format:"2006-01-02"`
+	Timeout time.Duration `form:"timeout"`
+	Ignored string        `form:"-"`
+}
+
+func TestBindValues(t *testing.T) {
+	values := url.Values{
+		"orderBy":  {"name"},
+		"page":     {"2"},
+		"desc":     {"on"},
+		"pageSize": {"20"},
+		"tag":      {"a", "b"},
+		"id":       {"1", "2", "3"},
+		"limit":    {"50"},
+		"since":    {"2021-06-01"},
+		"timeout":  {"5s"},
+		"Ignored":  {"x"},
+	}
+
+	q := Query{}
+	if err := BindValues(values, &q); err != nil {
+		t.Fatal(err)
+	}
+
+	if q.OrderBy != "name" || q.Page != 2 || !q.Descs || q.PageSize != 20 {
+		t.Errorf("embedded struct not bound: %+v", q.Struct)
+	}
+	if len(q.Tags) != 2 || len(q.IDs) != 3 || q.IDs[2] != 3 {
+		t.Errorf("slices not bound: %v %v", q.Tags, q.IDs)
+	}
+	if q.Limit == nil || *q.Limit != 50 {
+		t.Errorf("pointer not bound: %v", q.Limit)
+	}
+	if !q.Since.Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)) || q.Timeout != 5*time.Second {
+		t.Errorf("time not bound: %v %v", q.Since, q.Timeout)
+	}
+	if q.Ignored != "" {
+		t.Errorf("ignored field was bound: %q", q.Ignored)
+	}
+}
+
+type Filter struct {
+	Query  string `form:"q"`
+	Paging *Struct
+}
+
+func TestBindNestedPointer(t *testing.T) {
+	var f Filter
+	if err := BindValues(url.Values{"q": {"go"}}, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Paging != nil {
+		t.Errorf("nested pointer allocated without any of its keys: %+v", f.Paging)
+	}
+
+	if err := BindValues(url.Values{"page": {"3"}}, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Paging == nil || f.Paging.Page != 3 {
+		t.Errorf("nested pointer not bound: %+v", f.Paging)
+	}
+}
+
+func TestBindValuesErrors(t *testing.T) {
+	q := Query{}
+	err := BindValues(url.Values{"page": {"two"}, "id": {"1", "x"}}, &q)
+
+	var errs BindErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected BindErrors, got %v", err)
+	}
+	if len(errs) != 2 || errs[0].Field != "Page" || errs[1].Field != "IDs" || errs[1].Value != "x" {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+}
+
+func TestBindValuesValidates(t *testing.T) {
+	q := Query{}
+	err := BindValues(url.Values{"size": {"500"}}, &q)
+	if err == nil || !strings.Contains(err.Error(), "Size") {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+}
+
+type Upload struct {
+	Name string                `form:"name" validate:"min=1"`
+	File *multipart.FileHeader `form:"file"`
+}
+
+func TestBindRequestMultipart(t *testing.T) {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	_ = w.WriteField("name", "report")
+	fw, _ := w.CreateFormFile("file", "report.txt")
+	_, _ = fw.Write([]byte("hello"))
+	_ = w.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/upload", body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+
+	u := Upload{}
+	if err := BindRequest(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "report" || u.File == nil || u.File.Filename != "report.txt" {
+		t.Fatalf("unexpected result: %+v", u)
+	}
+}
+
+func TestBindRequestQuery(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/list?orderBy=id&page=3", nil)
+
+	s := Struct{}
+	if err := BindRequest(r, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.OrderBy != "id" || s.Page != 3 {
+		t.Fatalf("unexpected result: %+v", s)
+	}
+}
@@ -0,0 +1,345 @@
+// Package config loads a struct from YAML or JSON files, environment
+// variables and command line flags, applies `default` tags and validates the
+// result, reporting where every invalid value came from.
+//
+//	type Cfg struct {
+//		Address string `yaml:"address" env:"SSH_ADDRESS" validate:"min=1"`
+//		Port    string `yaml:"port" env:"SSH_PORT" flag:"port" default:"22" validate:"numericString; between=1..65535"`
+//	}
+//
+//	cfg := Cfg{}
+//	err := config.Load(&cfg, config.WithFile("ssh.yaml"), config.WithFlags(flag.CommandLine))
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/x86cloud/utils/validate"
+)
+
+// Source is where a value was loaded from.
+type Source struct {
+	// Kind is one of "default", "file", "env" or "flag".
+	Kind string
+	// Name is "file:line", the environment variable or "-flag".
+	Name string
+}
+
+func (s Source) String() string {
+	if s.Kind == "" {
+		return ""
+	}
+	return s.Kind + " " + s.Name
+}
+
+// Error is a problem with one value, or with a whole source when Field is
+// empty.
+type Error struct {
+	Field  string
+	Source Source
+	Err    error
+}
+
+func (e Error) Error() string {
+	if e.Source.Kind == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (from %s)", e.Err.Error(), e.Source)
+}
+
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Errors holds every problem found by Load.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type loader struct {
+	files     []string
+	envPrefix string
+	lookupEnv func(string) (string, bool)
+	flags     *flag.FlagSet
+
+	sources map[string]Source
+	errs    Errors
+}
+
+type Option func(*loader)
+
+// WithFile loads a YAML file, or a JSON file when it has a .json extension.
+// Later files override earlier ones.
+func WithFile(path string) Option {
+	return func(l *loader) {
+		l.files = append(l.files, path)
+	}
+}
+
+// WithEnvPrefix is prepended to the names of `env` tags.
+func WithEnvPrefix(prefix string) Option {
+	return func(l *loader) {
+		l.envPrefix = prefix
+	}
+}
+
+// WithLookupEnv replaces os.LookupEnv.
+func WithLookupEnv(lookup func(string) (string, bool)) Option {
+	return func(l *loader) {
+		l.lookupEnv = lookup
+	}
+}
+
+// WithFlags overlays the flags of a parsed FlagSet that were set on the command
+// line onto fields with a matching `flag` tag.
+func WithFlags(fs *flag.FlagSet) Option {
+	return func(l *loader) {
+		l.flags = fs
+	}
+}
+
+// Load fills the struct pointed to by dst from, in increasing priority,
+// `default` tags, files, environment variables and flags, then validates it
+// with validate.ValidateAll. All problems are returned together as Errors.
+func Load(dst interface{}, opts ...Option) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.New("config destination must be a non-nil pointer to a struct")
+	}
+
+	l := &loader{
+		lookupEnv: os.LookupEnv,
+		sources:   map[string]Source{},
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	l.applyDefaults(v.Elem(), "")
+	for _, file := range l.files {
+		l.loadFile(dst, file)
+	}
+	l.applyEnv(v.Elem(), "")
+	if l.flags != nil {
+		set := map[string]string{}
+		l.flags.Visit(func(f *flag.Flag) {
+			set[f.Name] = f.Value.String()
+		})
+		l.applyFlags(v.Elem(), "", set)
+	}
+
+	if err := validate.ValidateAll(dst); err != nil {
+		for _, fe := range err.(validate.Errors) {
+			l.errs = append(l.errs, Error{Field: fe.Field(), Source: l.sources[fe.Field()], Err: fe})
+		}
+	}
+
+	if len(l.errs) > 0 {
+		return l.errs
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fields calls fn for every exported leaf field of v, descending into nested
+// structs.
+func fields(v reflect.Value, path string, fn func(field reflect.Value, types reflect.StructField, path string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		types := t.Field(i)
+		if types.Anonymous && field.Kind() == reflect.Struct {
+			// embedded fields are promoted, as in validate
+			fields(field, path, fn)
+			continue
+		}
+		if types.PkgPath != "" {
+			continue
+		}
+
+		if field.Kind() == reflect.Struct && field.Type() != timeType {
+			fields(field, join(path, types.Name), fn)
+			continue
+		}
+		fn(field, types, join(path, types.Name))
+	}
+}
+
+func (l *loader) set(field reflect.Value, path, value string, source Source) {
+	if err := validate.SetString(field, value); err != nil {
+		l.errs = append(l.errs, Error{Field: path, Source: source, Err: fmt.Errorf("%q: %s", path, err.Error())})
+		return
+	}
+	l.sources[path] = source
+}
+
+func (l *loader) applyDefaults(v reflect.Value, path string) {
+	fields(v, path, func(field reflect.Value, types reflect.StructField, path string) {
+		def, ok := types.Tag.Lookup("default")
+		if !ok || !field.IsZero() {
+			return
+		}
+		l.set(field, path, def, Source{Kind: "default", Name: fmt.Sprintf("%q", def)})
+	})
+}
+
+func (l *loader) applyEnv(v reflect.Value, path string) {
+	fields(v, path, func(field reflect.Value, types reflect.StructField, path string) {
+		name := types.Tag.Get("env")
+		if name == "" || name == "-" {
+			return
+		}
+		name = l.envPrefix + name
+		if value, ok := l.lookupEnv(name); ok {
+			l.set(field, path, value, Source{Kind: "env", Name: name})
+		}
+	})
+}
+
+func (l *loader) applyFlags(v reflect.Value, path string, set map[string]string) {
+	fields(v, path, func(field reflect.Value, types reflect.StructField, path string) {
+		name := types.Tag.Get("flag")
+		if value, ok := set[name]; ok && name != "" {
+			l.set(field, path, value, Source{Kind: "flag", Name: "-" + name})
+		}
+	})
+}
+
+func (l *loader) loadFile(dst interface{}, file string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		l.errs = append(l.errs, Error{Source: Source{Kind: "file", Name: file}, Err: err})
+		return
+	}
+
+	isJSON := strings.EqualFold(filepath.Ext(file), ".json")
+	if isJSON {
+		err = json.Unmarshal(data, dst)
+		if se, ok := err.(*json.SyntaxError); ok {
+			err = fmt.Errorf("line %d: %s", lineOf(data, se.Offset), se.Error())
+		}
+	} else {
+		err = yaml.Unmarshal(data, dst)
+	}
+	if err != nil {
+		l.errs = append(l.errs, Error{Source: Source{Kind: "file", Name: file}, Err: err})
+		return
+	}
+
+	// JSON is a subset of YAML, so the node tree gives line numbers for both.
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err == nil {
+		l.locate(reflect.TypeOf(dst), node, "", file, isJSON)
+	}
+}
+
+func lineOf(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return strings.Count(string(data[:offset]), "\n") + 1
+}
+
+// locate records the file position of every value in node, keyed by the Go
+// field path that the value was decoded into.
+func (l *loader) locate(t reflect.Type, node *yaml.Node, path, file string, isJSON bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	if path != "" {
+		l.sources[path] = Source{Kind: "file", Name: fmt.Sprintf("%s:%d", file, node.Line)}
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			f, ok := fieldByKey(t, node.Content[i].Value, isJSON)
+			if !ok {
+				continue
+			}
+			l.locate(f.Type, node.Content[i+1], join(path, f.Name), file, isJSON)
+		}
+	case (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			l.locate(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i), file, isJSON)
+		}
+	}
+}
+
+// fieldByKey finds the field that a document key decodes into, following the
+// naming rules of encoding/json or yaml.v3.
+func fieldByKey(t reflect.Type, key string, isJSON bool) (reflect.StructField, bool) {
+	tagName := "yaml"
+	if isJSON {
+		tagName = "json"
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get(tagName), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+
+		// untagged embedded structs in JSON and ",inline" ones in YAML share
+		// the keys of their parent
+		inline := (isJSON && f.Anonymous && name == "") || (!isJSON && hasOption(tag[1:], "inline"))
+		if inline && f.Type.Kind() == reflect.Struct {
+			if sub, ok := fieldByKey(f.Type, key, isJSON); ok {
+				return sub, true
+			}
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+			if !isJSON {
+				name = strings.ToLower(name)
+			}
+		}
+		if name == key || (isJSON && strings.EqualFold(name, key)) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func hasOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
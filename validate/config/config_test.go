@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Host struct {
+	Name string `yaml:"name" json:"name" validate:"min=1"`
+	Port string `yaml:"port" json:"port" env:"PORT" flag:"port" default:"22" validate:"numericString; between=1..65535"`
+}
+
+type Cfg struct {
+	Host    Host          `yaml:"host" json:"host"`
+	Workers int           `yaml:"workers" json:"workers" env:"WORKERS" default:"4" validate:"between=1..64"`
+	Timeout time.Duration `yaml:"timeout" json:"timeout" default:"30s"`
+	Tags    []string      `yaml:"tags" json:"tags" env:"TAGS"`
+}
+
+func write(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func env(vars map[string]string) Option {
+	return WithLookupEnv(func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	})
+}
+
+func TestLoad(t *testing.T) {
+	file := write(t, "cfg.yaml", "host:\n  name: node1\nworkers: 8\n")
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("port", "", "")
+	if err := fs.Parse([]string{"-port", "2222"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Cfg{}
+	err := Load(&cfg, WithFile(file), WithFlags(fs), WithEnvPrefix("APP_"),
+		env(map[string]string{"APP_WORKERS": "16", "APP_TAGS": "a, b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Host.Name != "node1" || cfg.Host.Port != "2222" || cfg.Workers != 16 || cfg.Timeout != 30*time.Second {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+		t.Fatalf("unexpected tags: %v", cfg.Tags)
+	}
+}
+
+func TestLoadReportsSources(t *testing.T) {
+	file := write(t, "cfg.yaml", "host:\n  name: \"\"\n  port: \"70000\"\nworkers: 4\n")
+
+	cfg := Cfg{}
+	err := Load(&cfg, WithFile(file), env(map[string]string{"WORKERS": "100"}))
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+
+	want := map[string]string{
+		"Host.Name": "file " + file + ":2",
+		"Host.Port": "file " + file + ":3",
+		"Workers":   "env WORKERS",
+	}
+	for _, e := range errs {
+		if got := e.Source.String(); got != want[e.Field] {
+			t.Errorf("%s: got source %q, want %q", e.Field, got, want[e.Field])
+		}
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	file := write(t, "cfg.json", "{\n  \"host\": {\n    \"name\": \"node1\",\n    \"port\": \"0\"\n  }\n}\n")
+
+	cfg := Cfg{}
+	err := Load(&cfg, WithFile(file), env(nil))
+	if err == nil || !strings.Contains(err.Error(), file+":4") {
+		t.Fatalf("expected error located at line 4, got %v", err)
+	}
+}
+
+func TestLoadConversionErrors(t *testing.T) {
+	cfg := Cfg{}
+	err := Load(&cfg, env(map[string]string{"WORKERS": "many"}))
+	if err == nil || !strings.Contains(err.Error(), "env WORKERS") {
+		t.Fatalf("expected env conversion error, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Enum is implemented by named types with a fixed set of values. Validate
+// rejects any other value of such a type without needing a tag.
+//
+//	type Arch string
+//
+//	func (Arch) EnumValues() []interface{} {
+//		return []interface{}{Amd64, Arm64}
+//	}
+type Enum interface {
+	EnumValues() []interface{}
+}
+
+var enumType = reflect.TypeOf((*Enum)(nil)).Elem()
+
+// enumOf returns v as an Enum when its type, or a pointer to it, implements
+// the interface.
+func enumOf(v reflect.Value) (Enum, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+	if v.Type().Implements(enumType) && v.CanInterface() {
+		return v.Interface().(Enum), true
+	}
+	if reflect.PtrTo(v.Type()).Implements(enumType) {
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr.Interface().(Enum), true
+	}
+	return nil, false
+}
+
+// EnumValues returns the values allowed for a struct field, taken from an
+// `enum=a|b` validate tag or from the Enum interface of its type. Schema
+// generators use it to describe enumerations.
+func EnumValues(field reflect.StructField) ([]string, bool) {
+	opt := &Tag{}
+	buildTags(field.Tag.Get("validate"), opt)
+	if opt.Enum != "" && opt.Enum != "true" {
+		return strings.Split(opt.Enum, "|"), true
+	}
+
+	if e, ok := enumOf(reflect.New(field.Type).Elem()); ok {
+		return enumStrings(e.EnumValues()), true
+	}
+	return nil, false
+}
+
+func enumStrings(values []interface{}) []string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		s = append(s, fmt.Sprint(v))
+	}
+	return s
+}
+
+// EnumValidate checks v against the `enum=a|b` list, or against the values
+// of its Enum type for a bare "enum" tag.
+func (n Tag) EnumValidate(v reflect.Value) (bool, string) {
+	var allowed []string
+	if n.Enum == "true" {
+		e, ok := enumOf(v)
+		if !ok {
+			return true, ""
+		}
+		allowed = enumStrings(e.EnumValues())
+	} else {
+		allowed = strings.Split(n.Enum, "|")
+	}
+
+	value := fmt.Sprint(v)
+	for _, a := range allowed {
+		if strings.TrimSpace(a) == value {
+			return true, ""
+		}
+	}
+	return false, fmt.Sprintf("must be one of [%s], but %q was entered", strings.Join(allowed, ", "), value)
+}
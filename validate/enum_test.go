@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Arch string
+
+const (
+	Amd64 Arch = "amd64"
+	Arm64 Arch = "arm64"
+)
+
+func (Arch) EnumValues() []interface{} {
+	return []interface{}{Amd64, Arm64}
+}
+
+type Protocol int
+
+const (
+	TCP Protocol = iota + 1
+	UDP
+)
+
+func (*Protocol) EnumValues() []interface{} {
+	return []interface{}{TCP, UDP}
+}
+
+type Node struct {
+	Arch     Arch
+	Protocol Protocol
+	Phase    string `validate:"enum=Pending|Running|Succeeded"`
+}
+
+func TestEnum(t *testing.T) {
+	tests := []struct {
+		name  string
+		node  Node
+		valid bool
+		msg   string
+	}{
+		{"valid", Node{Arch: Arm64, Protocol: UDP, Phase: "Running"}, true, ""},
+		{"invalid arch", Node{Arch: "386", Protocol: TCP, Phase: "Running"}, false, "[amd64, arm64]"},
+		{"invalid protocol", Node{Arch: Amd64, Protocol: 3, Phase: "Running"}, false, "[1, 2]"},
+		{"invalid phase", Node{Arch: Amd64, Protocol: TCP, Phase: "Failed"}, false, "[Pending, Running, Succeeded]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.node)
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid, got %v", err)
+			}
+			if !tt.valid && (err == nil || !strings.Contains(err.Error(), tt.msg)) {
+				t.Fatalf("expected an error listing %s, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+func TestEnumValues(t *testing.T) {
+	typ := reflect.TypeOf(Node{})
+	for name, want := range map[string]string{
+		"Arch":     "amd64,arm64",
+		"Protocol": "1,2",
+		"Phase":    "Pending,Running,Succeeded",
+	} {
+		f, _ := typ.FieldByName(name)
+		values, ok := EnumValues(f)
+		if !ok || strings.Join(values, ",") != want {
+			t.Errorf("%s: got %v, want %s", name, values, want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RuleError is a rule that a value does not satisfy.
+type RuleError struct {
+	Rule   string
+	Reason string
+}
+
+func (e RuleError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("does not satisfy the condition of %s ", e.Rule)
+	}
+	return fmt.Sprintf("does not satisfy the condition of %s ( %s )", e.Rule, e.Reason)
+}
+
+// FieldError is a rule failure of one field.
+type FieldError struct {
+	// Path holds the field names from the validated struct down to the
+	// invalid field, slice elements as "Hosts[0]".
+	Path   []string
+	Rule   string
+	Reason string
+}
+
+// Field returns the dotted path of the invalid field, e.g. "Hosts[0].Port".
+func (e FieldError) Field() string {
+	return strings.Join(e.Path, ".")
+}
+
+func (e FieldError) Error() string {
+	quoted := make([]string, 0, len(e.Path))
+	for _, p := range e.Path {
+		quoted = append(quoted, fmt.Sprintf("%q", p))
+	}
+	return fmt.Sprintf("%s %s", strings.Join(quoted, "."), RuleError{Rule: e.Rule, Reason: e.Reason}.Error())
+}
+
+// Errors holds every invalid field found by ValidateAll.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
@@ -0,0 +1,201 @@
+package validate
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FileSystem is used by the file, dir, readable, executable, maxFileSize and
+// filePerm rules. Tests can replace it with an fstest.MapFS, whose names are
+// the validated paths without the leading slash.
+var FileSystem fs.FS = osFS{}
+
+// osFS opens names as given, relative to the working directory or absolute,
+// unlike os.DirFS.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// fsName maps a validated path to a name of FileSystem.
+func fsName(name string) string {
+	if _, ok := FileSystem.(osFS); ok {
+		return name
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+func statFile(name string) (fs.FileInfo, string) {
+	info, err := fs.Stat(FileSystem, fsName(name))
+	if err != nil {
+		return nil, fmt.Sprintf("cannot access %q: %s", name, unwrapPathError(err))
+	}
+	return info, ""
+}
+
+func unwrapPathError(err error) string {
+	if pe, ok := err.(*fs.PathError); ok {
+		return pe.Err.Error()
+	}
+	return err.Error()
+}
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40},
+	{"B", 1},
+}
+
+// parseSize parses sizes like "512", "64KB" or "1MiB" into bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	size := s
+	unit := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	if n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("size %q is too large", size)
+	}
+	return n * unit, nil
+}
+
+// The file rules skip empty paths, so optional settings such as a key file
+// can carry them.
+
+func (n Tag) FileValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	info, msg := statFile(name)
+	if info == nil {
+		return false, msg
+	}
+	if !info.Mode().IsRegular() {
+		return false, fmt.Sprintf("%q is not a regular file", name)
+	}
+	return true, ""
+}
+
+func (n Tag) DirValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	info, msg := statFile(name)
+	if info == nil {
+		return false, msg
+	}
+	if !info.IsDir() {
+		return false, fmt.Sprintf("%q is not a directory", name)
+	}
+	return true, ""
+}
+
+func (n Tag) AbsPathValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name != "" && !filepath.IsAbs(name) {
+		return false, fmt.Sprintf("%q is not an absolute path", name)
+	}
+	return true, ""
+}
+
+func (n Tag) RelPathValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name != "" && filepath.IsAbs(name) {
+		return false, fmt.Sprintf("%q is not a relative path", name)
+	}
+	return true, ""
+}
+
+func (n Tag) ReadableValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	f, err := FileSystem.Open(fsName(name))
+	if err != nil {
+		return false, fmt.Sprintf("%q is not readable: %s", name, unwrapPathError(err))
+	}
+	_ = f.Close()
+	return true, ""
+}
+
+func (n Tag) ExecutableValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	info, msg := statFile(name)
+	if info == nil {
+		return false, msg
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return false, fmt.Sprintf("%q is not executable", name)
+	}
+	return true, ""
+}
+
+func (n Tag) MaxFileSizeValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	limit, err := parseSize(n.MaxFileSize)
+	if err != nil {
+		return false, err.Error()
+	}
+	info, msg := statFile(name)
+	if info == nil {
+		return false, msg
+	}
+	if info.Size() > limit {
+		return false, fmt.Sprintf("%q is %d bytes, larger than %s", name, info.Size(), n.MaxFileSize)
+	}
+	return true, ""
+}
+
+// FilePermValidate rejects files with permission bits beyond the given mode,
+// e.g. a world-readable private key checked with filePerm=0600.
+func (n Tag) FilePermValidate(v reflect.Value) (bool, string) {
+	name := v.String()
+	if name == "" {
+		return true, ""
+	}
+	perm, err := strconv.ParseUint(n.FilePerm, 8, 32)
+	if err != nil {
+		return false, fmt.Sprintf("invalid parameter %q", n.FilePerm)
+	}
+	info, msg := statFile(name)
+	if info == nil {
+		return false, msg
+	}
+	if mode := info.Mode().Perm(); mode&^fs.FileMode(perm) != 0 {
+		return false, fmt.Sprintf("%q has permissions %#o, more permissive than %#o", name, mode, perm)
+	}
+	return true, ""
+}
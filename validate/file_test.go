@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type KeyCfg struct {
+	KeyFile string `validate:"absPath; file; readable; maxFileSize=1KiB; filePerm=0600"`
+	WorkDir string `validate:"relPath; dir"`
+	Helper  string `validate:"executable"`
+}
+
+func TestFileRules(t *testing.T) {
+	defer func(fsys fs.FS) { FileSystem = fsys }(FileSystem)
+	FileSystem = fstest.MapFS{
+		"root/.ssh/id_rsa":     {Data: []byte("key"), Mode: 0600},
+		"root/.ssh/id_rsa.pub": {Data: []byte("pub"), Mode: 0644},
+		"root/.ssh/big":        {Data: make([]byte, 2048), Mode: 0600},
+		"work/build.sh":        {Data: []byte("#!/bin/sh"), Mode: 0755},
+		"work/notes.txt":       {Data: []byte("notes"), Mode: 0644},
+	}
+
+	tests := []struct {
+		name string
+		cfg  KeyCfg
+		msg  string
+	}{
+		{"valid", KeyCfg{KeyFile: "/root/.ssh/id_rsa", WorkDir: "work", Helper: "/work/build.sh"}, ""},
+		{"empty paths are skipped", KeyCfg{}, ""},
+		{"relative key", KeyCfg{KeyFile: "root/.ssh/id_rsa"}, "not an absolute path"},
+		{"missing key", KeyCfg{KeyFile: "/root/.ssh/id_ed25519"}, "cannot access"},
+		{"key is a directory", KeyCfg{KeyFile: "/root/.ssh"}, "not a regular file"},
+		{"too large", KeyCfg{KeyFile: "/root/.ssh/big"}, "larger than 1KiB"},
+		{"world readable", KeyCfg{KeyFile: "/root/.ssh/id_rsa.pub"}, "more permissive than 0600"},
+		{"absolute dir", KeyCfg{WorkDir: "/work"}, "not a relative path"},
+		{"not a dir", KeyCfg{WorkDir: "work/notes.txt"}, "not a directory"},
+		{"not executable", KeyCfg{Helper: "/work/notes.txt"}, "not executable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.cfg)
+			if tt.msg == "" {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.msg) {
+				t.Fatalf("expected %q, got %v", tt.msg, err)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{"512": 512, "1KiB": 1024, "1MiB": 1 << 20, "2MB": 2e6, "10 K": 10240} {
+		if got, err := parseSize(s); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+	if _, err := parseSize("1XB"); err == nil {
+		t.Error("expected an error for an unknown unit")
+	}
+	for _, s := range []string{"9999999999G", "10000000TB", "9223372036854775808"} {
+		if got, err := parseSize(s); err == nil {
+			t.Errorf("parseSize(%q) = %d, expected an overflow error", s, got)
+		}
+	}
+	if got, err := parseSize("8388607TiB"); err != nil || got != 8388607<<40 {
+		t.Errorf("parseSize(8388607TiB) = %d, %v", got, err)
+	}
+}
@@ -0,0 +1,150 @@
+package validate
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	uuidPattern   = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+	ulidPattern   = `^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`
+	semverPattern = `^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`
+	hexPattern    = `^(0[xX])?[0-9a-fA-F]+$`
+	jwtPattern    = `^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*$`
+	sha256Pattern = `^[0-9a-fA-F]{64}$`
+	e164Pattern   = `^\+[1-9][0-9]{1,14}$`
+)
+
+var (
+	UUID_REG   = regexp.MustCompile(uuidPattern)
+	ULID_REG   = regexp.MustCompile(ulidPattern)
+	SEMVER_REG = regexp.MustCompile(semverPattern)
+	HEX_REG    = regexp.MustCompile(hexPattern)
+	JWT_REG    = regexp.MustCompile(jwtPattern)
+	SHA256_REG = regexp.MustCompile(sha256Pattern)
+	E164_REG   = regexp.MustCompile(e164Pattern)
+)
+
+// UuidValidate accepts any RFC 4122 UUID for "uuid", or only the given
+// version for "uuid=4".
+func (n Tag) UuidValidate(v reflect.Value) (bool, string) {
+	uuid := v.String()
+	if !UUID_REG.MatchString(uuid) {
+		return false, fmt.Sprintf("%q is not a valid UUID", uuid)
+	}
+	if n.Uuid == "true" {
+		return true, ""
+	}
+
+	if len(n.Uuid) != 1 || uuid[14] != n.Uuid[0] || !strings.ContainsRune("89abAB", rune(uuid[19])) {
+		return false, fmt.Sprintf("%q is not a valid version %s UUID", uuid, n.Uuid)
+	}
+	return true, ""
+}
+
+func (n Tag) UlidValidate(v reflect.Value) (bool, string) {
+	if !ULID_REG.MatchString(v.String()) {
+		return false, fmt.Sprintf("%q is not a valid ULID", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) SemverValidate(v reflect.Value) (bool, string) {
+	if !SEMVER_REG.MatchString(v.String()) {
+		return false, fmt.Sprintf("%q is not a valid semantic version", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) Base64Validate(v reflect.Value) (bool, string) {
+	s := v.String()
+	if _, err := base64.StdEncoding.DecodeString(s); s == "" || err != nil {
+		return false, "is not valid base64"
+	}
+	return true, ""
+}
+
+// Base64urlValidate accepts the URL-safe alphabet with or without padding.
+func (n Tag) Base64urlValidate(v reflect.Value) (bool, string) {
+	s := v.String()
+	if s == "" {
+		return false, "is not valid base64url"
+	}
+	if _, err := base64.URLEncoding.DecodeString(s); err == nil {
+		return true, ""
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(s); err == nil {
+		return true, ""
+	}
+	return false, "is not valid base64url"
+}
+
+func (n Tag) HexValidate(v reflect.Value) (bool, string) {
+	if !HEX_REG.MatchString(v.String()) {
+		return false, fmt.Sprintf("%q is not a valid hexadecimal string", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) JsonValidate(v reflect.Value) (bool, string) {
+	if !json.Valid([]byte(v.String())) {
+		return false, "is not valid JSON"
+	}
+	return true, ""
+}
+
+// JwtValidate checks the compact serialization: three base64url segments
+// whose header and payload are JSON objects. The signature is not verified.
+func (n Tag) JwtValidate(v reflect.Value) (bool, string) {
+	token := v.String()
+	if !JWT_REG.MatchString(token) {
+		return false, "is not a valid JWT"
+	}
+
+	parts := strings.Split(token, ".")
+	for _, part := range parts[:2] {
+		data, err := base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return false, "is not a valid JWT"
+		}
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return false, "is not a valid JWT"
+		}
+	}
+	return true, ""
+}
+
+func (n Tag) Sha256Validate(v reflect.Value) (bool, string) {
+	if !SHA256_REG.MatchString(v.String()) {
+		return false, fmt.Sprintf("%q is not a valid SHA-256 digest", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) Iso3166Alpha2Validate(v reflect.Value) (bool, string) {
+	if _, ok := iso3166Alpha2[v.String()]; !ok {
+		return false, fmt.Sprintf("%q is not an ISO 3166-1 alpha-2 country code", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) Iso4217Validate(v reflect.Value) (bool, string) {
+	if _, ok := iso4217[v.String()]; !ok {
+		return false, fmt.Sprintf("%q is not an ISO 4217 currency code", v.String())
+	}
+	return true, ""
+}
+
+func (n Tag) E164Validate(v reflect.Value) (bool, string) {
+	if !E164_REG.MatchString(v.String()) {
+		return false, fmt.Sprintf("%q is not an E.164 phone number", v.String())
+	}
+	return true, ""
+}
@@ -0,0 +1,83 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatRules(t *testing.T) {
+	const jwt = "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9." +
+		"eyJzdWIiOiIxMjM0NTY3ODkwIiwibmFtZSI6IkpvaG4gRG9lIiwiaWF0IjoxNTE2MjM5MDIyfQ." +
+		"SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
+
+	tests := []struct {
+		tag   string
+		value string
+		valid bool
+	}{
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567-e89b-12d3-a456-42661417400", false},
+		{"uuid", "123e4567e89b12d3a456426614174000", false},
+		{"uuid=4", "9b2f6c1e-3d4a-4f6b-8a1c-2e5d7f9a0b3c", true},
+		{"uuid=4", "123e4567-e89b-12d3-a456-426614174000", false},
+		{"uuid=4", "9b2f6c1e-3d4a-4f6b-ca1c-2e5d7f9a0b3c", false},
+
+		{"ulid", "01ARZ3NDEKTSV4RRFFQ69G5FAV", true},
+		{"ulid", "81ARZ3NDEKTSV4RRFFQ69G5FAV", false},
+		{"ulid", "01ARZ3NDEKTSV4RRFFQ69G5FAI", false},
+
+		{"semver", "1.2.3", true},
+		{"semver", "1.0.0-alpha.1+build.5", true},
+		{"semver", "v1.2.3", false},
+		{"semver", "01.2.3", false},
+		{"semver", "1.2", false},
+
+		{"base64", "aGVsbG8gd29ybGQ=", true},
+		{"base64", "aGVsbG8gd29ybGQ", false},
+		{"base64", "", false},
+		{"base64url", "PDw_Pz8-Pg==", true},
+		{"base64url", "PDw_Pz8-Pg", true},
+		{"base64url", "PDw/Pz8+Pg==", false},
+
+		{"hex", "deadBEEF", true},
+		{"hex", "0x1f", true},
+		{"hex", "0xzz", false},
+
+		{"json", `{"a": [1, 2]}`, true},
+		{"json", `"text"`, true},
+		{"json", `{"a": }`, false},
+
+		{"jwt", jwt, true},
+		{"jwt", "a.b.c", false},
+		{"jwt", "abc", false},
+
+		{"sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", true},
+		{"sha256", "e3b0c44298fc1c149afbf4c8996fb924", false},
+
+		{"iso3166Alpha2", "CN", true},
+		{"iso3166Alpha2", "cn", false},
+		{"iso3166Alpha2", "XX", false},
+
+		{"iso4217", "USD", true},
+		{"iso4217", "CNY", true},
+		{"iso4217", "ABC", false},
+
+		{"e164", "+8613800138000", true},
+		{"e164", "+14155552671", true},
+		{"e164", "13800138000", false},
+		{"e164", "+0123456", false},
+		{"e164", "+1234567890123456", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag+"/"+tt.value, func(t *testing.T) {
+			err := verification(reflect.ValueOf(tt.value), tt.tag, reflect.Value{})
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package ginx adapts the validate package to gin handlers.
+package ginx
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/x86cloud/utils/validate"
+)
+
+// Bind decodes the request into v and validates it with
+// validate.DecodeRequest. On failure it responds with an RFC 7807 problem
+// document, aborts the context and returns false.
+//
+//	func createUser(c *gin.Context) {
+//		user := User{}
+//		if !ginx.Bind(c, &user) {
+//			return
+//		}
+//		...
+//	}
+func Bind(c *gin.Context, v interface{}) bool {
+	if err := validate.DecodeRequest(c.Request, v); err != nil {
+		AbortWithProblem(c, err, v)
+		return false
+	}
+	return true
+}
+
+// AbortWithProblem responds with err converted by validate.NewProblem and
+// aborts the context.
+func AbortWithProblem(c *gin.Context, err error, v interface{}) {
+	p := validate.NewProblem(err, v)
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	_ = c.Error(err)
+	c.Header("Content-Type", validate.ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
@@ -0,0 +1,47 @@
+package ginx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/x86cloud/utils/validate"
+)
+
+type User struct {
+	Name string `json:"name" form:"name" validate:"min=4"`
+}
+
+func TestBind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users", func(c *gin.Context) {
+		user := User{}
+		if !Bind(c, &user) {
+			return
+		}
+		c.JSON(http.StatusCreated, user)
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name": "ab"}`))
+	r.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusUnprocessableEntity || w.Header().Get("Content-Type") != validate.ProblemContentType {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `"invalid-params"`) {
+		t.Fatalf("missing invalid-params: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=admin"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+}
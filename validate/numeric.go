@@ -0,0 +1,208 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	bigIntType     = reflect.TypeOf(big.Int{})
+	bigFloatType   = reflect.TypeOf(big.Float{})
+
+	errNotNumber = errors.New("is not a valid number")
+)
+
+// number is the value of a field converted for comparison. Binary floats keep
+// their bit size so that tag parameters are rounded the same way the field was.
+type number struct {
+	rat  *big.Rat
+	bits int
+}
+
+// isBigNumber reports whether t is big.Int, big.Float or a pointer to either.
+func isBigNumber(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == bigIntType || t == bigFloatType
+}
+
+// toNumber converts v into a number. ok is false when the numeric rules do not
+// apply to v at all, e.g. a plain string field without numericString.
+func toNumber(v reflect.Value, numericString bool) (num number, ok bool, err error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{rat: new(big.Rat).SetInt64(v.Int())}, true, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return number{rat: new(big.Rat).SetInt(new(big.Int).SetUint64(v.Uint()))}, true, nil
+
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return number{}, true, errNotNumber
+		}
+		return number{rat: new(big.Rat).SetFloat64(f), bits: v.Type().Bits()}, true, nil
+
+	case reflect.String:
+		if v.Type() != jsonNumberType && !numericString {
+			return number{}, false, nil
+		}
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(v.String()))
+		if !ok {
+			return number{}, true, errNotNumber
+		}
+		return number{rat: r}, true, nil
+
+	case reflect.Ptr:
+		if !isBigNumber(v.Type()) {
+			return number{}, false, nil
+		}
+		if v.IsNil() {
+			return number{}, true, errNotNumber
+		}
+		return toNumber(v.Elem(), numericString)
+
+	case reflect.Struct:
+		if !v.CanAddr() {
+			tmp := reflect.New(v.Type()).Elem()
+			tmp.Set(v)
+			v = tmp
+		}
+		switch x := v.Addr().Interface().(type) {
+		case *big.Int:
+			return number{rat: new(big.Rat).SetInt(x)}, true, nil
+		case *big.Float:
+			if x.IsInf() {
+				return number{}, true, errNotNumber
+			}
+			r, _ := x.Rat(nil)
+			return number{rat: r}, true, nil
+		}
+	}
+
+	return number{}, false, nil
+}
+
+// parse converts a tag parameter into a rational using the same precision as
+// the field value.
+func (num number) parse(param string) (*big.Rat, error) {
+	param = strings.TrimSpace(param)
+	if num.bits != 0 {
+		f, err := strconv.ParseFloat(param, num.bits)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid parameter %q", param)
+		}
+		return new(big.Rat).SetFloat64(f), nil
+	}
+
+	r, ok := new(big.Rat).SetString(param)
+	if !ok {
+		return nil, fmt.Errorf("invalid parameter %q", param)
+	}
+	return r, nil
+}
+
+// multipleOf reports whether num is an integer multiple of m. Binary floats are
+// compared with a small tolerance, so 0.3 is a multiple of 0.1.
+func (num number) multipleOf(m *big.Rat) bool {
+	if m.Sign() == 0 {
+		return false
+	}
+	if num.bits == 0 {
+		return new(big.Rat).Quo(num.rat, m).IsInt()
+	}
+
+	v, _ := num.rat.Float64()
+	d, _ := m.Float64()
+	q := v / d
+	return math.Abs(q-math.Round(q)) <= 1e-9*math.Max(1, math.Abs(q))
+}
+
+// compareNumber compares the value held by v with param, returning -1, 0 or 1.
+// ok is false when the numeric rules do not apply to v.
+func (n Tag) compareNumber(v reflect.Value, param string) (cmp int, ok bool, err error) {
+	num, ok, err := toNumber(v, n.NumericString)
+	if !ok || err != nil {
+		return 0, ok, err
+	}
+
+	p, err := num.parse(param)
+	if err != nil {
+		return 0, true, err
+	}
+	return num.rat.Cmp(p), true, nil
+}
+
+// checkNumber is the shared body of the comparison rules: it fails with msg
+// when pass rejects the comparison result.
+func (n Tag) checkNumber(v reflect.Value, param string, pass func(cmp int) bool, msg string) (bool, string) {
+	cmp, ok, err := n.compareNumber(v, param)
+	if !ok {
+		return true, ""
+	}
+	if err != nil {
+		return false, err.Error()
+	}
+	if !pass(cmp) {
+		return false, msg
+	}
+	return true, ""
+}
+
+func (n Tag) NumericStringValidate(v reflect.Value) (bool, string) {
+	if v.Kind() != reflect.String {
+		return true, ""
+	}
+	if _, ok := new(big.Rat).SetString(strings.TrimSpace(v.String())); !ok {
+		return false, fmt.Sprintf("%q %s", v.String(), errNotNumber)
+	}
+	return true, ""
+}
+
+func (n Tag) MultipleOfValidate(v reflect.Value) (bool, string) {
+	num, ok, err := toNumber(v, n.NumericString)
+	if !ok {
+		return true, ""
+	}
+	if err != nil {
+		return false, err.Error()
+	}
+
+	m, err := num.parse(n.MultipleOf)
+	if err != nil {
+		return false, err.Error()
+	}
+	if !num.multipleOf(m) {
+		return false, fmt.Sprintf("must be a multiple of %s", n.MultipleOf)
+	}
+	return true, ""
+}
+
+func (n Tag) BetweenValidate(v reflect.Value) (bool, string) {
+	bounds := strings.SplitN(n.Between, "..", 2)
+	if len(bounds) != 2 {
+		return false, fmt.Sprintf("invalid parameter %q, expected min..max", n.Between)
+	}
+
+	msg := fmt.Sprintf("between %s and %s", strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1]))
+	if ok, m := n.checkNumber(v, bounds[0], func(cmp int) bool { return cmp >= 0 }, msg); !ok {
+		return false, m
+	}
+	return n.checkNumber(v, bounds[1], func(cmp int) bool { return cmp <= 0 }, msg)
+}
+
+func (n Tag) PositiveValidate(v reflect.Value) (bool, string) {
+	return n.checkNumber(v, "0", func(cmp int) bool { return cmp > 0 }, "must be positive")
+}
+
+func (n Tag) NegativeValidate(v reflect.Value) (bool, string) {
+	return n.checkNumber(v, "0", func(cmp int) bool { return cmp < 0 }, "must be negative")
+}
@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+type Port string
+
+type Numbers struct {
+	Int8    int8        `validate:"gt=-5; lt=5"`
+	Uintptr uintptr     `validate:"lte=10"`
+	Float   float32     `validate:"eq=0.1"`
+	Number  json.Number `validate:"gte=1.5"`
+	BigInt  *big.Int    `validate:"gt=100000000000000000000"`
+	Big     big.Float   `validate:"positive"`
+	Port    Port        `validate:"numericString; gt=0; lte=65535"`
+	Step    int         `validate:"multipleOf=5; between=10..100"`
+	Ratio   float64     `validate:"multipleOf=0.1; negative"`
+}
+
+func validNumbers() Numbers {
+	bi, _ := new(big.Int).SetString("100000000000000000001", 10)
+	return Numbers{
+		Int8:    -4,
+		Uintptr: 10,
+		Float:   0.1,
+		Number:  "1.5",
+		BigInt:  bi,
+		Big:     *big.NewFloat(0.5),
+		Port:    "22",
+		Step:    15,
+		Ratio:   -0.3,
+	}
+}
+
+func TestValidateNumeric(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(n *Numbers)
+		valid  bool
+	}{
+		{"valid", func(n *Numbers) {}, true},
+		{"int8 out of range", func(n *Numbers) { n.Int8 = -5 }, false},
+		{"uintptr too large", func(n *Numbers) { n.Uintptr = 11 }, false},
+		{"float32 not equal", func(n *Numbers) { n.Float = 0.2 }, false},
+		{"json.Number too small", func(n *Numbers) { n.Number = "1.49" }, false},
+		{"json.Number invalid", func(n *Numbers) { n.Number = "abc" }, false},
+		{"big.Int too small", func(n *Numbers) { n.BigInt = big.NewInt(1) }, false},
+		{"big.Int nil", func(n *Numbers) { n.BigInt = nil }, false},
+		{"big.Float not positive", func(n *Numbers) { n.Big = *big.NewFloat(0) }, false},
+		{"numeric string not a number", func(n *Numbers) { n.Port = "ssh" }, false},
+		{"numeric string too large", func(n *Numbers) { n.Port = "65536" }, false},
+		{"numeric string upper bound", func(n *Numbers) { n.Port = "65535" }, true},
+		{"not a multiple", func(n *Numbers) { n.Step = 16 }, false},
+		{"outside between", func(n *Numbers) { n.Step = 105 }, false},
+		{"between lower bound", func(n *Numbers) { n.Step = 10 }, true},
+		{"float not a multiple", func(n *Numbers) { n.Ratio = -0.35 }, false},
+		{"not negative", func(n *Numbers) { n.Ratio = 0 }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := validNumbers()
+			tt.modify(&n)
+			err := Validate(&n)
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestComparisonMessage(t *testing.T) {
+	v := struct {
+		Float float64 `validate:"eq=1.5"`
+	}{Float: 2}
+
+	err := Validate(v)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	want := `"Float" does not satisfy the condition of Eq ( equal to 1.5 )`
+	if err.Error() != want {
+		t.Fatalf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestNumericStringOptIn(t *testing.T) {
+	v := struct {
+		Port string `validate:"gt=100"`
+	}{Port: "22"}
+
+	if err := Validate(v); err != nil {
+		t.Fatalf("string fields must not be compared without numericString: %v", err)
+	}
+}
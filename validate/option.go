@@ -9,6 +9,8 @@ import (
 
 type Tag struct {
 	//	Tag    string
+	NumericString bool `method:"NumericStringValidate"`
+
 	Eq  string `method:"Equals"`
 	Gt  string `method:"GreaterThan"`
 	Gte string `method:"GreaterThanOrEqual"`
@@ -16,13 +18,47 @@ type Tag struct {
 	Lte string `method:"LessThanOrEqual"`
 	Ne  string `method:"NotEqual"`
 
-	Min    int `method:"MaxValidate"`
-	Max    int `method:"MinValidate"`
+	MultipleOf string `method:"MultipleOfValidate"`
+	Between    string `method:"BetweenValidate"`
+	Positive   bool   `method:"PositiveValidate"`
+	Negative   bool   `method:"NegativeValidate"`
+
+	// Min and Max are the least and greatest length of a string.
+	Min    int `method:"MinValidate"`
+	Max    int `method:"MaxValidate"`
 	Length int `method:"LengthValidate"`
 
 	Email   bool `method:"EmailValidate"`
 	Url     bool `method:"UrlValidate"`
 	NoSpace bool `method:"NoSpaceValidate"`
+
+	Uuid          string `method:"UuidValidate"`
+	Ulid          bool   `method:"UlidValidate"`
+	Semver        bool   `method:"SemverValidate"`
+	Base64        bool   `method:"Base64Validate"`
+	Base64url     bool   `method:"Base64urlValidate"`
+	Hex           bool   `method:"HexValidate"`
+	Json          bool   `method:"JsonValidate"`
+	Jwt           bool   `method:"JwtValidate"`
+	Sha256        bool   `method:"Sha256Validate"`
+	Iso3166Alpha2 bool   `method:"Iso3166Alpha2Validate"`
+	Iso4217       bool   `method:"Iso4217Validate"`
+	E164          bool   `method:"E164Validate"`
+
+	Password string `method:"PasswordValidate"`
+	Enum     string `method:"EnumValidate"`
+
+	File        bool   `method:"FileValidate"`
+	Dir         bool   `method:"DirValidate"`
+	AbsPath     bool   `method:"AbsPathValidate"`
+	RelPath     bool   `method:"RelPathValidate"`
+	Readable    bool   `method:"ReadableValidate"`
+	Executable  bool   `method:"ExecutableValidate"`
+	MaxFileSize string `method:"MaxFileSizeValidate"`
+	FilePerm    string `method:"FilePermValidate"`
+
+	// parent is the struct that holds the validated field.
+	parent reflect.Value
 }
 
 func buildTags(tag string, opt interface{}) {
@@ -69,13 +105,13 @@ func buildTags(tag string, opt interface{}) {
 				field.SetFloat(0.00)
 			}
 			field.SetFloat(v)
-		case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 			v, err := strconv.Atoi(value)
 			if err != nil {
 				field.SetInt(0)
 			}
 			field.SetInt(int64(v))
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 			v, err := strconv.ParseUint(value, 0, 64)
 			if err != nil {
 				field.SetUint(0)
@@ -125,169 +161,31 @@ func (n Tag) UrlValidate(v reflect.Value) (bool, string) {
 func (n Tag) LengthValidate(v reflect.Value) (bool, string) {
 	length := len(v.String())
 	if length != n.Length {
-		return false , fmt.Sprintf("required %d characters, but %d characters were entered", n.Length, length)
+		return false, fmt.Sprintf("required %d characters, but %d characters were entered", n.Length, length)
 	}
 	return true, ""
 }
 
 func (n Tag) NotEqual(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Ne, 64)
-		if err != nil || v.Float() == eq {
-			return false, fmt.Sprintf("cannot be equal to %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Ne, 10, 64)
-		if err != nil || v.Int() == eq {
-			return false, fmt.Sprintf("cannot be equal to %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Ne, 10, 64)
-		if err != nil || v.Uint() == eq {
-			return false, fmt.Sprintf("cannot be equal to %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Ne, func(cmp int) bool { return cmp != 0 }, fmt.Sprintf("cannot be equal to %s", n.Ne))
 }
 
 func (n Tag) LessThanOrEqual(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Lte, 64)
-		if err != nil || v.Float() > eq {
-			return false, fmt.Sprintf("less than or equal %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Lte, 10, 64)
-		if err != nil || v.Int() > eq {
-			return false, fmt.Sprintf("less than or equal to %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Lte, 10, 64)
-		if err != nil || v.Uint() > eq {
-			return false, fmt.Sprintf("less than or equal to %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Lte, func(cmp int) bool { return cmp <= 0 }, fmt.Sprintf("less than or equal to %s", n.Lte))
 }
 
 func (n Tag) LessThan(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Lt, 64)
-		if err != nil || v.Float() >= eq {
-			return false, fmt.Sprintf("less than %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Lt, 10, 64)
-		if err != nil || v.Int() >= eq {
-			return false, fmt.Sprintf("less than %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Lt, 10, 64)
-		if err != nil || v.Uint() >= eq {
-			return false, fmt.Sprintf("less than %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Lt, func(cmp int) bool { return cmp < 0 }, fmt.Sprintf("less than %s", n.Lt))
 }
 
 func (n Tag) GreaterThanOrEqual(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Gte, 64)
-		if err != nil || v.Float() < eq {
-			return false, fmt.Sprintf("greate than or equal to %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Gte, 10, 64)
-		if err != nil || v.Int() < eq {
-			return false, fmt.Sprintf("greate than or equal to %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Gte, 10, 64)
-		if err != nil || v.Uint() < eq {
-			return false, fmt.Sprintf("greate than or equal to %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Gte, func(cmp int) bool { return cmp >= 0 }, fmt.Sprintf("greater than or equal to %s", n.Gte))
 }
 
 func (n Tag) Equals(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Eq, 64)
-		if err != nil || v.Float() != eq {
-			return false, fmt.Sprintf("equal to %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Eq, 10, 64)
-		if err != nil || v.Int() != eq {
-			return false, fmt.Sprintf("equal to %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Eq, 10, 64)
-		if err != nil || v.Uint() != eq {
-			return false, fmt.Sprintf("equal to %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Eq, func(cmp int) bool { return cmp == 0 }, fmt.Sprintf("equal to %s", n.Eq))
 }
 
 func (n Tag) GreaterThan(v reflect.Value) (bool, string) {
-	switch v.Kind() {
-	case reflect.Float32, reflect.Float64:
-		eq, err := strconv.ParseFloat(n.Gt, 64)
-		if err != nil || v.Float() <= eq {
-			return false, fmt.Sprintf("greate than %f", eq)
-		}
-		return true, ""
-
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		eq, err := strconv.ParseInt(n.Gt, 10, 64)
-		if err != nil || v.Int() <= eq {
-			return false, fmt.Sprintf("greate than %d", eq)
-		}
-		return true, ""
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		eq, err := strconv.ParseUint(n.Gt, 10, 64)
-		if err != nil || v.Uint() <= eq {
-			return false, fmt.Sprintf("greate than %d", eq)
-		}
-		return true, ""
-	}
-
-	return true, ""
+	return n.checkNumber(v, n.Gt, func(cmp int) bool { return cmp > 0 }, fmt.Sprintf("greater than %s", n.Gt))
 }
@@ -0,0 +1,151 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Fields is a set of dotted field paths, e.g. "Host.Port", as used by
+// FieldError.Field.
+type Fields map[string]struct{}
+
+// NewFields returns the set of the given paths and all of their parents.
+func NewFields(paths ...string) Fields {
+	fields := Fields{}
+	for _, path := range paths {
+		fields.Add(path)
+	}
+	return fields
+}
+
+// Add adds path and its parent structs to the set.
+func (f Fields) Add(path string) {
+	for {
+		f[path] = struct{}{}
+		i := strings.LastIndex(path, ".")
+		if i < 0 {
+			return
+		}
+		path = path[:i]
+	}
+}
+
+func (f Fields) Has(path string) bool {
+	_, ok := f[path]
+	return ok
+}
+
+// ValidatePartial validates only the fields in present, so that zero values
+// of fields a PATCH request did not send do not fail rules like min=4. Nested
+// structs are descended into when present, and a present slice has all of
+// its elements validated.
+func ValidatePartial(i interface{}, present Fields) error {
+	if present == nil {
+		present = Fields{}
+	}
+	w := &walker{present: present}
+	w.walk(reflect.ValueOf(i), nil)
+	if len(w.errs) == 0 {
+		return nil
+	}
+	return w.errs[0]
+}
+
+// PresentFields returns the fields of v set by the JSON document data,
+// matching keys the way encoding/json does. Keys set to null are left out
+// because a JSON merge patch uses them to delete values.
+//
+//	present, _ := validate.PresentFields(body, &user)
+//	_ = json.Unmarshal(body, &user)
+//	err := validate.ValidatePartial(&user, present)
+func PresentFields(data []byte, v interface{}) (Fields, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("PresentFields requires a struct")
+	}
+
+	fields := Fields{}
+	if err := presentFields(data, t, "", fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func presentFields(data []byte, t reflect.Type, path string, fields Fields) error {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		return err
+	}
+
+	for key, raw := range object {
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			continue
+		}
+		name, ft, ok := jsonField(t, key)
+		if !ok {
+			continue
+		}
+		name = joinPath(path, name)
+		fields.Add(name)
+
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			if err := presentFields(raw, ft, name, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField finds the field that key decodes into, returning its path relative
+// to t. Untagged embedded structs are searched like encoding/json does.
+func jsonField(t reflect.Type, key string) (string, reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			et := f.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				// promoted fields keep the parent's path, as in Validate
+				if sub, ft, ok := jsonField(et, key); ok {
+					return sub, ft, true
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		if strings.EqualFold(name, key) {
+			return f.Name, f.Type, true
+		}
+	}
+	return "", nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,73 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type PatchHost struct {
+	Address string `json:"address" validate:"min=4"`
+	Port    string `json:"port" validate:"numericString; between=1..65535"`
+}
+
+type PatchUser struct {
+	Name  string      `json:"name" validate:"min=4; max=16"`
+	Email string      `json:"email" validate:"email"`
+	Host  PatchHost   `json:"host"`
+	Hosts []PatchHost `json:"hosts"`
+}
+
+func TestPresentFields(t *testing.T) {
+	body := []byte(`{"NAME": "admin", "email": null, "host": {"port": "22"}, "unknown": 1}`)
+
+	present, err := PresentFields(body, &PatchUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := NewFields("Name", "Host.Port")
+	if len(present) != len(want) {
+		t.Fatalf("got %v, want %v", present, want)
+	}
+	for path := range want {
+		if !present.Has(path) {
+			t.Errorf("missing %q in %v", path, present)
+		}
+	}
+}
+
+func TestValidatePartial(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"only valid name", `{"name": "admin"}`, true},
+		{"invalid name", `{"name": "ad"}`, false},
+		{"nested field only", `{"host": {"port": "22"}}`, true},
+		{"invalid nested field", `{"host": {"port": "0"}}`, false},
+		{"slice validated in full", `{"hosts": [{"port": "22"}]}`, false},
+		{"empty patch", `{}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := PatchUser{}
+			present, err := PresentFields([]byte(tt.body), &u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.body), &u); err != nil {
+				t.Fatal(err)
+			}
+
+			err = ValidatePartial(&u, present)
+			if tt.valid && err != nil {
+				t.Fatalf("expected valid, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy describes the requirements a password must meet.
+type PasswordPolicy struct {
+	MinLength int
+	Upper     bool
+	Lower     bool
+	Digit     bool
+	Special   bool
+	// MaxRepeat limits runs of the same character, 0 means no limit.
+	MaxRepeat int
+	// NotFields names sibling fields, such as the username, whose value must
+	// not appear in the password.
+	NotFields []string
+	// Common rejects passwords found in CommonPasswords.
+	Common bool
+}
+
+// PasswordList reports whether a password is too common to be accepted.
+type PasswordList interface {
+	Contains(password string) bool
+}
+
+// PasswordSet is a case-insensitive PasswordList backed by a map.
+type PasswordSet map[string]struct{}
+
+func NewPasswordSet(passwords ...string) PasswordSet {
+	set := PasswordSet{}
+	for _, p := range passwords {
+		set[strings.ToLower(p)] = struct{}{}
+	}
+	return set
+}
+
+func (s PasswordSet) Contains(password string) bool {
+	_, ok := s[strings.ToLower(password)]
+	return ok
+}
+
+var (
+	// DefaultPasswordPolicy is used by a bare "password" tag.
+	DefaultPasswordPolicy = PasswordPolicy{
+		MinLength: 8,
+		Upper:     true,
+		Lower:     true,
+		Digit:     true,
+		MaxRepeat: 3,
+		Common:    true,
+	}
+
+	// CommonPasswords is consulted by policies with Common set. Replace it to
+	// plug in a larger list.
+	CommonPasswords PasswordList = NewPasswordSet(
+		"123456", "123456789", "12345678", "1234567890", "password", "password1",
+		"Password1", "Passw0rd", "P@ssw0rd", "qwerty", "qwerty123", "abc123",
+		"111111", "123123", "admin", "admin123", "root", "letmein", "welcome",
+		"iloveyou", "monkey", "dragon", "football", "baseball", "sunshine",
+		"princess", "1q2w3e4r", "zaq12wsx", "changeme", "Aa123456",
+	)
+)
+
+// parsePasswordPolicy parses the tag value of the password rule, e.g.
+// "min:12,upper,lower,digit,special,maxRepeat:2,notField:Username,common".
+// A bare "password" tag selects DefaultPasswordPolicy.
+func parsePasswordPolicy(s string) (PasswordPolicy, error) {
+	if s == "true" {
+		return DefaultPasswordPolicy, nil
+	}
+
+	policy := PasswordPolicy{}
+	for _, item := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), ":", 2)
+		arg := ""
+		if len(kv) == 2 {
+			arg = strings.TrimSpace(kv[1])
+		}
+
+		var err error
+		switch kv[0] {
+		case "min":
+			policy.MinLength, err = strconv.Atoi(arg)
+		case "maxRepeat":
+			policy.MaxRepeat, err = strconv.Atoi(arg)
+		case "upper":
+			policy.Upper = true
+		case "lower":
+			policy.Lower = true
+		case "digit":
+			policy.Digit = true
+		case "special":
+			policy.Special = true
+		case "common":
+			policy.Common = true
+		case "notField":
+			policy.NotFields = append(policy.NotFields, arg)
+		default:
+			return policy, fmt.Errorf("unknown password option %q", kv[0])
+		}
+		if err != nil {
+			return policy, fmt.Errorf("invalid password option %q", item)
+		}
+	}
+	return policy, nil
+}
+
+// Check returns every requirement the password does not meet. fields resolves
+// the values of NotFields and may be nil.
+func (p PasswordPolicy) Check(password string, fields func(name string) string) []string {
+	var (
+		unmet                        []string
+		upper, lower, digit, special bool
+		run, longest                 int
+		prev                         rune
+	)
+
+	for i, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			special = true
+		}
+
+		if i > 0 && r == prev {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+		prev = r
+	}
+
+	if n := len([]rune(password)); n < p.MinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.Upper && !upper {
+		unmet = append(unmet, "an uppercase letter")
+	}
+	if p.Lower && !lower {
+		unmet = append(unmet, "a lowercase letter")
+	}
+	if p.Digit && !digit {
+		unmet = append(unmet, "a digit")
+	}
+	if p.Special && !special {
+		unmet = append(unmet, "a special character")
+	}
+	if p.MaxRepeat > 0 && longest > p.MaxRepeat {
+		unmet = append(unmet, fmt.Sprintf("no character repeated more than %d times in a row", p.MaxRepeat))
+	}
+	for _, name := range p.NotFields {
+		if fields == nil {
+			continue
+		}
+		value := fields(name)
+		if value != "" && strings.Contains(strings.ToLower(password), strings.ToLower(value)) {
+			unmet = append(unmet, fmt.Sprintf("must not contain %s", name))
+		}
+	}
+	if p.Common && CommonPasswords != nil && CommonPasswords.Contains(password) {
+		unmet = append(unmet, "must not be a commonly used password")
+	}
+
+	return unmet
+}
+
+func (n Tag) PasswordValidate(v reflect.Value) (bool, string) {
+	policy, err := parsePasswordPolicy(n.Password)
+	if err != nil {
+		return false, err.Error()
+	}
+
+	unmet := policy.Check(v.String(), n.siblingString)
+	if len(unmet) > 0 {
+		return false, "password does not meet: " + strings.Join(unmet, "; ")
+	}
+	return true, ""
+}
+
+// siblingString returns the value of the named string field of the struct
+// holding the validated field, or "" when there is none.
+func (n Tag) siblingString(name string) string {
+	if !n.parent.IsValid() || n.parent.Kind() != reflect.Struct {
+		return ""
+	}
+	field := n.parent.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
@@ -0,0 +1,61 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+type Account struct {
+	Username string
+	Password string `validate:"password=min:10,upper,lower,digit,special,maxRepeat:2,notField:Username,common"`
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		password string
+		unmet    []string
+	}{
+		{"Str0ng!Passw", nil},
+		{"short", []string{"at least 10", "uppercase", "digit", "special"}},
+		{"Baaa1!cdefg", []string{"repeated more than 2"}},
+		{"Xadmin1!xyz", []string{"must not contain Username"}},
+		{"ALLUPPER123!", []string{"lowercase"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			err := Validate(Account{Username: "admin", Password: tt.password})
+			if len(tt.unmet) == 0 {
+				if err != nil {
+					t.Fatalf("expected valid, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.unmet {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("%q does not mention %q", err.Error(), want)
+				}
+			}
+		})
+	}
+}
+
+func TestCommonPasswords(t *testing.T) {
+	defer func(list PasswordList) { CommonPasswords = list }(CommonPasswords)
+	CommonPasswords = NewPasswordSet("Company2021!")
+
+	v := struct {
+		Password string `validate:"password"`
+	}{Password: "company2021!X"}
+	if err := Validate(v); err != nil {
+		t.Fatalf("expected valid, got %v", err)
+	}
+
+	v.Password = "COMPANY2021!"
+	if err := Validate(v); err == nil || !strings.Contains(err.Error(), "commonly used") {
+		t.Fatalf("expected a common password error, got %v", err)
+	}
+}
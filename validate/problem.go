@@ -0,0 +1,207 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// ValidationProblemType is the "type" of validation problems. Point it at the
+// documentation of your API if you have one.
+var ValidationProblemType = "about:blank"
+
+// InvalidParam is one entry of the "invalid-params" extension of a Problem.
+type InvalidParam struct {
+	// Name is the dotted path of the parameter using json tag names.
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	// Path is the JSON Pointer of the parameter in the request body.
+	Path string `json:"path,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// NewProblem converts an error returned by Validate, ValidateAll,
+// ValidatePartial or a Bind function into a Problem. v is the validated value
+// and is used to name parameters after their json tags; it may be nil. Other
+// errors become a 400 Bad Request problem.
+func NewProblem(err error, v interface{}) *Problem {
+	var t reflect.Type
+	if v != nil {
+		t = reflect.TypeOf(v)
+	}
+
+	var (
+		fe  FieldError
+		fes Errors
+		be  BindErrors
+		p   *Problem
+	)
+	switch {
+	case errors.As(err, &p):
+		return p
+	case errors.As(err, &fes):
+	case errors.As(err, &fe):
+		fes = Errors{fe}
+	case errors.As(err, &be):
+		problem := &Problem{
+			Type:   ValidationProblemType,
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+		for _, e := range be {
+			problem.InvalidParams = append(problem.InvalidParams, InvalidParam{Name: e.Key, Reason: e.Err.Error()})
+		}
+		return problem
+	default:
+		return &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+	}
+
+	problem := &Problem{
+		Type:   ValidationProblemType,
+		Title:  http.StatusText(http.StatusUnprocessableEntity),
+		Status: http.StatusUnprocessableEntity,
+		Detail: fes.Error(),
+	}
+	for _, e := range fes {
+		names := jsonNames(t, e.Path)
+		reason := e.Reason
+		if reason == "" {
+			reason = "does not satisfy the condition of " + e.Rule
+		}
+		problem.InvalidParams = append(problem.InvalidParams, InvalidParam{
+			Name:   strings.Join(names, "."),
+			Reason: reason,
+			Path:   "/" + strings.Join(names, "/"),
+		})
+	}
+	return problem
+}
+
+// Write sends the problem as application/problem+json.
+func (p *Problem) Write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// WriteProblem writes err as a problem details response, see NewProblem.
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error, v interface{}) {
+	p := NewProblem(err, v)
+	if p.Instance == "" && r != nil {
+		p.Instance = r.URL.Path
+	}
+	p.Write(w)
+}
+
+// DecodeRequest decodes a JSON body, or query and form values otherwise,
+// into v and validates it with ValidateAll.
+func DecodeRequest(r *http.Request, v interface{}) error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		if err := BindRequest(r, v); err != nil {
+			var fe FieldError
+			if errors.As(err, &fe) {
+				// report every invalid field, not only the first
+				return ValidateAll(v)
+			}
+			return err
+		}
+		return nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: err.Error(),
+		}
+	}
+	return ValidateAll(v)
+}
+
+// Handler returns an http.Handler that decodes each request into a value made
+// by newValue, validates it and calls next with it. Failures are answered with
+// a problem details document.
+//
+//	http.Handle("/users", validate.Handler(
+//		func() interface{} { return &User{} },
+//		func(w http.ResponseWriter, r *http.Request, v interface{}) {
+//			user := v.(*User)
+//			...
+//		}))
+func Handler(newValue func() interface{}, next func(w http.ResponseWriter, r *http.Request, v interface{})) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := newValue()
+		if err := DecodeRequest(r, v); err != nil {
+			WriteProblem(w, r, err, v)
+			return
+		}
+		next(w, r, v)
+	})
+}
+
+// jsonNames translates a Go field path into json names. Slice indexes become
+// their own segment.
+func jsonNames(t reflect.Type, path []string) []string {
+	names := make([]string, 0, len(path))
+	for _, segment := range path {
+		name, index := segment, ""
+		if i := strings.IndexByte(segment, '['); i >= 0 && strings.HasSuffix(segment, "]") {
+			name, index = segment[:i], segment[i+1:len(segment)-1]
+		}
+
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+					name = tag
+				}
+				t = f.Type
+			} else {
+				t = nil
+			}
+		} else {
+			t = nil
+		}
+		names = append(names, name)
+
+		if index != "" {
+			if _, err := strconv.Atoi(index); err == nil {
+				names = append(names, index)
+			}
+			for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				t = t.Elem()
+			}
+		}
+	}
+	return names
+}
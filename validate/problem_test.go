@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CreateHost struct {
+	Name  string      `json:"name" validate:"min=4"`
+	Hosts []PatchHost `json:"hosts"`
+}
+
+func TestHandlerProblem(t *testing.T) {
+	called := false
+	h := Handler(func() interface{} { return &CreateHost{} },
+		func(w http.ResponseWriter, r *http.Request, v interface{}) {
+			called = true
+		})
+
+	body := `{"name": "ab", "hosts": [{"address": "10.0.0.1", "port": "0"}]}`
+	r := httptest.NewRequest(http.MethodPost, "/hosts", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if called {
+		t.Fatal("next must not be called for invalid input")
+	}
+	if w.Code != http.StatusUnprocessableEntity || w.Header().Get("Content-Type") != ProblemContentType {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	p := Problem{}
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Status != http.StatusUnprocessableEntity || p.Instance != "/hosts" || len(p.InvalidParams) != 2 {
+		t.Fatalf("unexpected problem: %+v", p)
+	}
+	if p.InvalidParams[0].Name != "name" || p.InvalidParams[1].Path != "/hosts/0/port" {
+		t.Fatalf("unexpected invalid params: %+v", p.InvalidParams)
+	}
+}
+
+func TestHandlerMalformedJSON(t *testing.T) {
+	h := Handler(func() interface{} { return &CreateHost{} },
+		func(w http.ResponseWriter, r *http.Request, v interface{}) {})
+
+	r := httptest.NewRequest(http.MethodPost, "/hosts", strings.NewReader(`{"name":`))
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
+
+func TestHandlerValid(t *testing.T) {
+	var got *CreateHost
+	h := Handler(func() interface{} { return &CreateHost{} },
+		func(w http.ResponseWriter, r *http.Request, v interface{}) {
+			got = v.(*CreateHost)
+		})
+
+	r := httptest.NewRequest(http.MethodPost, "/hosts", strings.NewReader(`{"name": "node1"}`))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got == nil || got.Name != "node1" {
+		t.Fatalf("unexpected value: %+v", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"strings"
 )
 
 const (
@@ -21,64 +22,180 @@ var (
 // if validate ,return "", true
 // if not, return the first invalid field name, and false
 func Validate(i interface{}) error {
+	w := &walker{}
+	w.walk(reflect.ValueOf(i), nil)
+	if len(w.errs) == 0 {
+		return nil
+	}
+	return w.errs[0]
+}
 
-	refValue := reflect.ValueOf(i)
-	refType := reflect.TypeOf(i)
+// ValidateAll validates every field instead of stopping at the first invalid
+// one. The returned error is of type Errors.
+func ValidateAll(i interface{}) error {
+	w := &walker{all: true}
+	w.walk(reflect.ValueOf(i), nil)
+	if len(w.errs) == 0 {
+		return nil
+	}
+	return w.errs
+}
 
-	// 传入的是指针情况，需要使用Elem()获取元素
-	if refValue.Kind() == reflect.Ptr {
-		refValue = reflect.ValueOf(i).Elem()
-		refType = reflect.TypeOf(i).Elem()
+type walker struct {
+	all  bool
+	errs Errors
+	// present limits validation to the listed fields when not nil.
+	present Fields
+	// visiting holds the pointers on the current path, to stop at cycles.
+	visiting map[visit]bool
+}
+
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// fail records an invalid field, it returns false when walking should stop.
+func (w *walker) fail(path []string, name string, err error) bool {
+	fe := FieldError{Path: append(append([]string{}, path...), name)}
+	if re, ok := err.(RuleError); ok {
+		fe.Rule, fe.Reason = re.Rule, re.Reason
+	} else {
+		fe.Reason = err.Error()
 	}
+	w.errs = append(w.errs, fe)
+	return w.all
+}
 
+// isStruct reports whether v holds a struct, directly or through pointers and
+// interfaces. Nil values are not structs.
+func isStruct(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return v.Kind() == reflect.Struct && !isBigNumber(v.Type())
+}
+
+func (w *walker) walk(refValue reflect.Value, path []string) bool {
+	// 传入的是指针情况，需要使用Elem()获取元素
+	for refValue.Kind() == reflect.Ptr || refValue.Kind() == reflect.Interface {
+		if refValue.IsNil() {
+			return true
+		}
+		if refValue.Kind() == reflect.Ptr {
+			key := visit{ptr: refValue.Pointer(), typ: refValue.Type()}
+			if w.visiting[key] {
+				// a pointer cycle, the struct is already being validated
+				return true
+			}
+			if w.visiting == nil {
+				w.visiting = map[visit]bool{}
+			}
+			w.visiting[key] = true
+			defer delete(w.visiting, key)
+		}
+		refValue = refValue.Elem()
+	}
+	if refValue.Kind() != reflect.Struct {
+		return true
+	}
+	refType := refValue.Type()
 
 	for i := 0; i < refType.NumField(); i++ {
 		field := refValue.Field(i)
 		types := refType.Field(i)
 
-		//if field.Kind() == reflect.Ptr {
-		//	field = refValue.Field(i).Elem()
-		//	types = refType.Field(i)
-		//}
-
 		tag := types.Tag.Get("validate")
 		if tag == IgnoreFields {
 			continue
 		}
 
-		switch field.Kind() {
-		case reflect.Float32, reflect.Float64,
-			reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64,
-			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			if err := verification(field, tag); err != nil {
-				return fmt.Errorf("\"%s\" %s", types.Name, err.Error())
-			}
-		case reflect.String:
-			if err := verification(field, tag); err != nil {
-				return fmt.Errorf("\"%s\" %s", types.Name, err.Error())
+		// embedded structs are promoted into the parent's path
+		if types.Anonymous && isStruct(field) {
+			if !w.walk(field, path) {
+				return false
 			}
+			continue
+		}
+		if types.PkgPath != "" {
+			continue
+		}
+
+		if w.present != nil && !w.present.Has(strings.Join(append(path, types.Name), ".")) {
+			continue
+		}
+		if !w.field(refValue, field, tag, path, types.Name) {
+			return false
+		}
+	}
+	return true
+}
 
-		case reflect.Struct:
-			if err := Validate(field.Interface()); err != nil {
-				return fmt.Errorf("\"%s\".%s", types.Name, err.Error())
+// field validates one field of parent, following pointers and interfaces to
+// the value they hold.
+func (w *walker) field(parent, field reflect.Value, tag string, path []string, name string) bool {
+	if isBigNumber(field.Type()) {
+		if err := verification(field, tag, parent); err != nil && !w.fail(path, name, err) {
+			return false
+		}
+		return true
+	}
+
+	switch field.Kind() {
+	case reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if err := verification(field, tag, parent); err != nil && !w.fail(path, name, err) {
+			return false
+		}
+	case reflect.String:
+		if err := verification(field, tag, parent); err != nil && !w.fail(path, name, err) {
+			return false
+		}
+
+	case reflect.Ptr, reflect.Interface:
+		if field.IsNil() {
+			return true
+		}
+		if isStruct(field) {
+			return w.walk(field, append(path, name))
+		}
+		return w.field(parent, field.Elem(), tag, path, name)
+
+	case reflect.Struct:
+		return w.walk(field, append(path, name))
+
+	case reflect.Array, reflect.Slice:
+		// a sent slice replaces the old one, so its elements are validated in full
+		present := w.present
+		w.present = nil
+		defer func() { w.present = present }()
+		for j := 0; j < field.Len(); j++ {
+			if !isStruct(field.Index(j)) {
+				continue
 			}
-		case reflect.Array, reflect.Slice:
-			for j := 0; j < field.Len(); j++ {
-				//Validate(field.Index(j).Interface())
-				if err := Validate(field.Index(j).Interface()); err != nil {
-					return fmt.Errorf("\"%s[%d]\".%s", types.Name, j, err.Error())
-				}
+			if !w.walk(field.Index(j), append(path, fmt.Sprintf("%s[%d]", name, j))) {
+				return false
 			}
-
 		}
 	}
-	return nil
+	return true
 }
 
-func verification(v interface{}, tags string) error {
+// verification runs the rules in tags against v. parent is the struct holding
+// v, used by rules that compare against sibling fields; it may be invalid.
+func verification(v interface{}, tags string, parent reflect.Value) error {
 
-	opt := &Tag{}
+	opt := &Tag{parent: parent}
 	buildTags(tags, opt)
+	if rv, ok := v.(reflect.Value); ok && opt.Enum == "" {
+		if _, isEnum := enumOf(rv); isEnum {
+			opt.Enum = "true"
+		}
+	}
 
 	optType := reflect.TypeOf(opt).Elem()
 	optValue := reflect.ValueOf(opt).Elem()
@@ -96,13 +213,13 @@ func verification(v interface{}, tags string) error {
 			}
 			results := method.Call(param)
 
-			if len(results) == 1 && !results[0].Bool()  {
-				return fmt.Errorf("does not satisfy the condition of %s ", filed.Name)
+			if len(results) == 1 && !results[0].Bool() {
+				return RuleError{Rule: filed.Name}
 			}
 
 			if len(results) == 2 {
 				if !results[0].Bool() {
-					return fmt.Errorf("does not satisfy the condition of %s ( %s )", filed.Name, results[1].String())
+					return RuleError{Rule: filed.Name, Reason: results[1].String()}
 				}
 
 			}
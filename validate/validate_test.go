@@ -1,6 +1,7 @@
 package validate
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -38,3 +39,116 @@ func BenchmarkValidate(b *testing.B) {
 		}
 	}
 }
+
+func TestValidateAll(t *testing.T) {
+	v := Test{
+		Eq:   1,
+		Name: "short",
+		TestSub: []TestSub{
+			{Eq: 10, EqFloat: 12, Name: "toolong"},
+		},
+	}
+
+	err := ValidateAll(v)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+
+	fields := []string{"Eq", "EqFloat", "Name", "TestSub[0].Name"}
+	if len(errs) != len(fields) {
+		t.Fatalf("expected %d errors, got %v", len(fields), errs)
+	}
+	for i, field := range fields {
+		if errs[i].Field() != field {
+			t.Errorf("error %d: got field %q, want %q", i, errs[i].Field(), field)
+		}
+	}
+
+	if Validate(v).Error() != errs[0].Error() {
+		t.Errorf("Validate must return the first error")
+	}
+}
+
+type Base struct {
+	ID string `validate:"length=3"`
+}
+
+type inner struct {
+	Name string `validate:"min=4"`
+}
+
+type Tree struct {
+	Base
+	Name     string `validate:"min=4"`
+	Parent   *Tree
+	Children []*Tree
+	Value    interface{}
+	secret   inner
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	err := ValidateAll(Tree{Base: Base{ID: "x"}, Name: "root"})
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 || errs[0].Field() != "ID" {
+		t.Fatalf("embedded field must be promoted, got %v", err)
+	}
+}
+
+func TestValidateUnexported(t *testing.T) {
+	tree := Tree{Base: Base{ID: "abc"}, Name: "root", secret: inner{Name: "x"}}
+	if err := Validate(tree); err != nil {
+		t.Fatalf("unexported fields must be skipped, got %v", err)
+	}
+}
+
+func TestValidateInterface(t *testing.T) {
+	tree := Tree{Base: Base{ID: "abc"}, Name: "root", Value: &Base{ID: "toolong"}}
+	err := Validate(tree)
+	if err == nil || err.(FieldError).Field() != "Value.ID" {
+		t.Fatalf("interface must be validated by its dynamic type, got %v", err)
+	}
+}
+
+func TestValidateCycle(t *testing.T) {
+	root := &Tree{Base: Base{ID: "abc"}, Name: "root"}
+	child := &Tree{Base: Base{ID: "abc"}, Name: "x", Parent: root}
+	root.Children = []*Tree{child}
+	root.Parent = root
+
+	err := ValidateAll(root)
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 || errs[0].Field() != "Children[0].Name" {
+		t.Fatalf("expected one error for the child, got %v", err)
+	}
+}
+
+type Bounds struct {
+	Short string `validate:"max=4"`
+	Long  string `validate:"min=2"`
+	Tags  []string
+}
+
+func TestMinMax(t *testing.T) {
+	tests := []struct {
+		bounds Bounds
+		want   string
+	}{
+		{Bounds{Short: "abcd", Long: "ab"}, ""},
+		{Bounds{Short: "", Long: "abcdef"}, ""},
+		{Bounds{Short: "abcd", Long: "a"}, "no less than 2 characters"},
+		{Bounds{Short: "abcde", Long: "ab"}, "no more than 4 characters"},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.bounds)
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%+v: got %v, want %q", tt.bounds, err, tt.want)
+		}
+	}
+}
+
+func TestValidateScalarSlice(t *testing.T) {
+	if err := Validate(Bounds{Long: "abc", Tags: []string{"a", "b"}}); err != nil {
+		t.Fatalf("elements that are not structs must be skipped, got %v", err)
+	}
+}